@@ -0,0 +1,28 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+)
+
+type ArrayLiteral struct {
+	BaseNode
+	Elements []Expression
+}
+
+func (literal *ArrayLiteral) ToString() string {
+	var output bytes.Buffer
+	var elements = []string{}
+
+	for _, element := range literal.Elements {
+		elements = append(elements, element.ToString())
+	}
+
+	output.WriteString("[")
+	output.WriteString(strings.Join(elements, ", "))
+	output.WriteString("]")
+
+	return output.String()
+}
+
+func (literal *ArrayLiteral) GetExpressionNode() {}
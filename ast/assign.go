@@ -0,0 +1,28 @@
+package ast
+
+import "bytes"
+
+// AssignExpression stores Value into Target, an identifier or an index
+// expression; a compound Operator such as "+=" combines it with the old value.
+type AssignExpression struct {
+	BaseNode
+	Target   Expression
+	Operator string
+	Value    Expression
+}
+
+func (expression *AssignExpression) ToString() string {
+	var output bytes.Buffer
+
+	output.WriteString("(")
+	output.WriteString(expression.Target.ToString())
+	output.WriteString(" ")
+	output.WriteString(expression.Operator)
+	output.WriteString(" ")
+	output.WriteString(expression.Value.ToString())
+	output.WriteString(")")
+
+	return output.String()
+}
+
+func (expression *AssignExpression) GetExpressionNode() {}
@@ -7,6 +7,7 @@ import (
 
 type Node interface {
 	GetTokenLiteral() string
+	GetSpan() token.Span
 	ToString() string
 }
 
@@ -15,6 +16,7 @@ type BaseNode struct {
 }
 
 func (node *BaseNode) GetTokenLiteral() string { return node.Token.Literal }
+func (node *BaseNode) GetSpan() token.Span     { return node.Token.Span }
 func (node *BaseNode) ToString() string        { return node.GetTokenLiteral() }
 
 type Statement interface {
@@ -40,6 +42,17 @@ func (program *Program) GetNextTokenLiteral() string {
 	return ""
 }
 
+func (program *Program) GetSpan() token.Span {
+	if len(program.Statements) == 0 {
+		return token.Span{}
+	}
+
+	return token.Span{
+		Start: program.Statements[0].GetSpan().Start,
+		End:   program.Statements[len(program.Statements)-1].GetSpan().End,
+	}
+}
+
 func (program *Program) ToString() string {
 	var output bytes.Buffer
 
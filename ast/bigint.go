@@ -0,0 +1,14 @@
+package ast
+
+import "math/big"
+
+type BigIntLiteral struct {
+	BaseNode
+	Value *big.Int
+}
+
+func (literal *BigIntLiteral) ToString() string {
+	return literal.Token.Literal
+}
+
+func (literal *BigIntLiteral) GetExpressionNode() {}
@@ -9,6 +9,8 @@ type CallExpression struct {
 	BaseNode
 	Function  Expression
 	Arguments []Expression
+	// IsTail marks a call whose value the enclosing function returns as is
+	IsTail bool
 }
 
 func (expression *CallExpression) ToString() string {
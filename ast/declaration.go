@@ -1,6 +1,9 @@
 package ast
 
-import "bytes"
+import (
+	"bytes"
+	"compiler/token"
+)
 
 type LetStatement struct {
 	BaseNode
@@ -8,6 +11,10 @@ type LetStatement struct {
 	Value Expression
 }
 
+func (statement *LetStatement) IsConstant() bool {
+	return statement.Token.Type == token.CONST
+}
+
 func (statement *LetStatement) ToString() string {
 	var output bytes.Buffer
 
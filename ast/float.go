@@ -0,0 +1,12 @@
+package ast
+
+type FloatLiteral struct {
+	BaseNode
+	Value float64
+}
+
+func (literal *FloatLiteral) ToString() string {
+	return literal.Token.Literal
+}
+
+func (literal *FloatLiteral) GetExpressionNode() {}
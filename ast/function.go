@@ -7,6 +7,7 @@ import (
 
 type FunctionLiteral struct {
 	BaseNode
+	Name       string
 	Parameters []*Identifier
 	Body       *BlockStatement
 }
@@ -0,0 +1,33 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+)
+
+type HashPair struct {
+	Key   Expression
+	Value Expression
+}
+
+type HashLiteral struct {
+	BaseNode
+	Pairs []HashPair
+}
+
+func (literal *HashLiteral) ToString() string {
+	var output bytes.Buffer
+	var pairs = []string{}
+
+	for _, pair := range literal.Pairs {
+		pairs = append(pairs, pair.Key.ToString()+": "+pair.Value.ToString())
+	}
+
+	output.WriteString("{")
+	output.WriteString(strings.Join(pairs, ", "))
+	output.WriteString("}")
+
+	return output.String()
+}
+
+func (literal *HashLiteral) GetExpressionNode() {}
@@ -0,0 +1,23 @@
+package ast
+
+import "bytes"
+
+type IndexExpression struct {
+	BaseNode
+	Left  Expression
+	Index Expression
+}
+
+func (expression *IndexExpression) ToString() string {
+	var output bytes.Buffer
+
+	output.WriteString("(")
+	output.WriteString(expression.Left.ToString())
+	output.WriteString("[")
+	output.WriteString(expression.Index.ToString())
+	output.WriteString("])")
+
+	return output.String()
+}
+
+func (expression *IndexExpression) GetExpressionNode() {}
@@ -0,0 +1,101 @@
+package ast
+
+import "bytes"
+
+type WhileStatement struct {
+	BaseNode
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (statement *WhileStatement) ToString() string {
+	var output bytes.Buffer
+
+	output.WriteString("while")
+	output.WriteString(statement.Condition.ToString())
+	output.WriteString(" ")
+	output.WriteString(statement.Body.ToString())
+
+	return output.String()
+}
+
+func (statement *WhileStatement) GetStatementNode() {}
+
+type ForStatement struct {
+	BaseNode
+	Init      Statement
+	Condition Expression
+	Update    Statement
+	Body      *BlockStatement
+}
+
+func (statement *ForStatement) ToString() string {
+	var output bytes.Buffer
+
+	output.WriteString("for(")
+
+	if statement.Init != nil {
+		output.WriteString(statement.Init.ToString())
+	}
+
+	output.WriteString(";")
+
+	if statement.Condition != nil {
+		output.WriteString(statement.Condition.ToString())
+	}
+
+	output.WriteString(";")
+
+	if statement.Update != nil {
+		output.WriteString(statement.Update.ToString())
+	}
+
+	output.WriteString(") ")
+	output.WriteString(statement.Body.ToString())
+
+	return output.String()
+}
+
+func (statement *ForStatement) GetStatementNode() {}
+
+type ForInStatement struct {
+	BaseNode
+	Variable *Identifier
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+func (statement *ForInStatement) ToString() string {
+	var output bytes.Buffer
+
+	output.WriteString("for(")
+	output.WriteString(statement.Variable.ToString())
+	output.WriteString(" in ")
+	output.WriteString(statement.Iterable.ToString())
+	output.WriteString(") ")
+	output.WriteString(statement.Body.ToString())
+
+	return output.String()
+}
+
+func (statement *ForInStatement) GetStatementNode() {}
+
+type BreakStatement struct {
+	BaseNode
+}
+
+func (statement *BreakStatement) ToString() string {
+	return statement.GetTokenLiteral() + ";"
+}
+
+func (statement *BreakStatement) GetStatementNode() {}
+
+type ContinueStatement struct {
+	BaseNode
+}
+
+func (statement *ContinueStatement) ToString() string {
+	return statement.GetTokenLiteral() + ";"
+}
+
+func (statement *ContinueStatement) GetStatementNode() {}
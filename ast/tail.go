@@ -0,0 +1,48 @@
+package ast
+
+// MarkTailCalls flags the calls whose value the function body returns as is:
+// return values, and the last expression of the body, looking through if
+// branches. Calls inside try are left alone, since their errors and finally
+// blocks belong to the frame making the call.
+func MarkTailCalls(body *BlockStatement) {
+	markTailStatements(body.Statements, true)
+}
+
+func markTailStatements(statements []Statement, isTail bool) {
+	for index, statement := range statements {
+		isLast := isTail && index == len(statements)-1
+
+		switch statement := statement.(type) {
+		case *ReturnStatement:
+			markTailExpression(statement.Value, true)
+
+		case *ExpressionStatement:
+			markTailExpression(statement.Expression, isLast)
+
+		case *WhileStatement:
+			markTailStatements(statement.Body.Statements, false)
+
+		case *ForStatement:
+			markTailStatements(statement.Body.Statements, false)
+
+		case *ForInStatement:
+			markTailStatements(statement.Body.Statements, false)
+		}
+	}
+}
+
+// markTailExpression flags expression itself only when isTail, but still
+// looks into if branches for return statements.
+func markTailExpression(expression Expression, isTail bool) {
+	switch expression := expression.(type) {
+	case *CallExpression:
+		expression.IsTail = isTail
+
+	case *IfExpression:
+		markTailStatements(expression.Consequence.Statements, isTail)
+
+		if expression.Alternative != nil {
+			markTailStatements(expression.Alternative.Statements, isTail)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package ast
+
+import "bytes"
+
+// TryExpression evaluates to the value of Block, or of Catch when Block
+// raises an error; Parameter, when present, names the caught error. Either
+// Catch or Finally may be missing, not both.
+type TryExpression struct {
+	BaseNode
+	Block     *BlockStatement
+	Parameter *Identifier
+	Catch     *BlockStatement
+	Finally   *BlockStatement
+}
+
+func (expression *TryExpression) ToString() string {
+	var output bytes.Buffer
+
+	output.WriteString("try ")
+	output.WriteString(expression.Block.ToString())
+
+	if expression.Catch != nil {
+		output.WriteString(" catch ")
+
+		if expression.Parameter != nil {
+			output.WriteString("(" + expression.Parameter.ToString() + ") ")
+		}
+
+		output.WriteString(expression.Catch.ToString())
+	}
+
+	if expression.Finally != nil {
+		output.WriteString(" finally ")
+		output.WriteString(expression.Finally.ToString())
+	}
+
+	return output.String()
+}
+
+func (expression *TryExpression) GetExpressionNode() {}
+
+type ThrowStatement struct {
+	BaseNode
+	Value Expression
+}
+
+func (statement *ThrowStatement) ToString() string {
+	var output bytes.Buffer
+
+	output.WriteString(statement.GetTokenLiteral())
+	output.WriteString(" ")
+
+	if statement.Value != nil {
+		output.WriteString(statement.Value.ToString())
+	}
+
+	output.WriteString(";")
+
+	return output.String()
+}
+
+func (statement *ThrowStatement) GetStatementNode() {}
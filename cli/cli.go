@@ -0,0 +1,305 @@
+package cli
+
+import (
+	"compiler/ast"
+	"compiler/compiler"
+	"compiler/diagnostics"
+	"compiler/evaluator"
+	"compiler/lexer"
+	"compiler/object"
+	"compiler/parser"
+	"compiler/repl"
+	"compiler/token"
+	"compiler/vm"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+)
+
+const (
+	EXIT_OK      = 0
+	EXIT_FAILURE = 1
+	EXIT_USAGE   = 2
+)
+
+const USAGE = `usage: compiler <command> [arguments]
+
+commands:
+  run [-engine=eval|vm] [-checked] [options] <file>   execute a script
+  repl                                                 start an interactive session
+  parse <file>                                         print the parsed program
+  tokens <file>                                        print the lexer output
+
+A <file> of - reads the script from the standard input.
+
+run and parse accept -diagnostics=text|pretty|json to choose how errors
+are reported. run accepts -max-depth=N to bound nested calls and, with the
+eval engine, -max-steps=N to bound evaluation steps and -timeout=duration
+to bound the running time.
+`
+
+type Streams struct {
+	In  io.Reader
+	Out io.Writer
+	Err io.Writer
+}
+
+func Run(args []string, streams Streams) int {
+	if len(args) == 0 {
+		return runRepl(streams)
+	}
+
+	switch args[0] {
+	case "run":
+		return runScript(args[1:], streams)
+
+	case "repl":
+		return runRepl(streams)
+
+	case "parse":
+		return runParse(args[1:], streams)
+
+	case "tokens":
+		return runTokens(args[1:], streams)
+
+	case "help", "-h", "--help":
+		fmt.Fprint(streams.Out, USAGE)
+		return EXIT_OK
+
+	default:
+		fmt.Fprintf(streams.Err, "unknown command %q\n\n%s", args[0], USAGE)
+		return EXIT_USAGE
+	}
+}
+
+func runRepl(streams Streams) int {
+	currentUser, err := user.Current()
+
+	if err != nil {
+		fmt.Fprintln(streams.Err, err)
+		return EXIT_FAILURE
+	}
+
+	fmt.Fprintf(streams.Out, "Hello %s! This is REPL, feel fre to input any command\n", currentUser.Username)
+	repl.Start(streams.In, streams.Out)
+
+	return EXIT_OK
+}
+
+func runScript(args []string, streams Streams) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	flags.SetOutput(streams.Err)
+	engine := flags.String("engine", "eval", "execution engine: eval or vm")
+	checked := flags.Bool("checked", false, "report integer overflow instead of wrapping")
+	maxDepth := flags.Int("max-depth", object.DEFAULT_MAX_CALL_DEPTH, "maximum depth of nested calls")
+	maxSteps := flags.Int64("max-steps", 0, "abort the eval engine after this many steps, 0 for no limit")
+	timeout := flags.Duration("timeout", 0, "abort the eval engine after this long, 0 for no limit")
+	format := flags.String("diagnostics", "text", "diagnostics format: text, pretty or json")
+
+	if err := flags.Parse(args); err != nil {
+		return EXIT_USAGE
+	}
+
+	if exitCode := checkDiagnosticsFormat(*format, streams); exitCode != EXIT_OK {
+		return exitCode
+	}
+
+	program, source, exitCode := parseFile(flags.Args(), *format, streams)
+
+	if program == nil {
+		return exitCode
+	}
+
+	var result object.Object
+
+	settings := &object.Settings{CheckedArithmetic: *checked, MaxCallDepth: *maxDepth}
+
+	switch *engine {
+	case "eval":
+		limits := evaluator.Limits{MaxSteps: *maxSteps}
+
+		if *timeout > 0 {
+			limits.Deadline = time.Now().Add(*timeout)
+		}
+
+		result = evaluator.EvalContext(context.Background(), program, object.NewEnvironmentWithSettings(settings), limits)
+
+	case "vm":
+		bytecodeCompiler := compiler.New()
+
+		if err := bytecodeCompiler.Compile(program); err != nil {
+			fmt.Fprintln(streams.Err, err)
+			return EXIT_FAILURE
+		}
+
+		machine := vm.NewWithSettings(bytecodeCompiler.Bytecode(), make([]object.Object, vm.GLOBALS_SIZE), settings)
+
+		if err := machine.Run(); err != nil {
+			fmt.Fprintln(streams.Err, err)
+			return EXIT_FAILURE
+		}
+
+		result = machine.Result()
+
+	default:
+		fmt.Fprintf(streams.Err, "unknown engine %q\n", *engine)
+		return EXIT_USAGE
+	}
+
+	if result == nil || result.GetObjectType() == object.NULL_OBJ {
+		return EXIT_OK
+	}
+
+	if errorObj, ok := result.(*object.Error); ok {
+		exitCode := reportDiagnostics([]diagnostics.Diagnostic{errorObj.ToDiagnostic()}, *format, source, streams)
+
+		// the json output stays a single document
+		if *format != "json" {
+			fmt.Fprint(streams.Err, errorObj.StackTrace())
+		}
+
+		return exitCode
+	}
+
+	fmt.Fprintln(streams.Out, result.Inspect())
+
+	return EXIT_OK
+}
+
+func runParse(args []string, streams Streams) int {
+	flags := flag.NewFlagSet("parse", flag.ContinueOnError)
+	flags.SetOutput(streams.Err)
+	format := flags.String("diagnostics", "text", "diagnostics format: text, pretty or json")
+
+	if err := flags.Parse(args); err != nil {
+		return EXIT_USAGE
+	}
+
+	if exitCode := checkDiagnosticsFormat(*format, streams); exitCode != EXIT_OK {
+		return exitCode
+	}
+
+	program, _, exitCode := parseFile(flags.Args(), *format, streams)
+
+	if program == nil {
+		return exitCode
+	}
+
+	for _, statement := range program.Statements {
+		fmt.Fprintln(streams.Out, statement.ToString())
+	}
+
+	return EXIT_OK
+}
+
+func runTokens(args []string, streams Streams) int {
+	lexerInstance, closeFile, exitCode := openFile(args, io.Discard, streams)
+
+	if exitCode != EXIT_OK {
+		return exitCode
+	}
+
+	defer closeFile()
+	lexerInstance.RetainComments()
+
+	for {
+		nextToken := lexerInstance.ReadNextToken()
+		fmt.Fprintf(streams.Out, "%s %s %q\n", nextToken.Span.Start.ToString(), nextToken.Type, nextToken.Literal)
+
+		if nextToken.Type == token.EOF {
+			return EXIT_OK
+		}
+	}
+}
+
+// parseFile keeps a copy of the source only for the pretty diagnostics,
+// the one format quoting source lines; the others leave the lexer streaming.
+func parseFile(args []string, format string, streams Streams) (*ast.Program, string, int) {
+	var source strings.Builder
+	var copied io.Writer = io.Discard
+
+	if format == "pretty" {
+		copied = &source
+	}
+
+	lexerInstance, closeFile, exitCode := openFile(args, copied, streams)
+
+	if exitCode != EXIT_OK {
+		return nil, "", exitCode
+	}
+
+	defer closeFile()
+
+	parserInstance := parser.New(lexerInstance)
+	program := parserInstance.ParseProgram()
+	input := source.String()
+
+	if parsingDiagnostics := parserInstance.GetDiagnostics(); len(parsingDiagnostics) > 0 {
+		return nil, input, reportDiagnostics(parsingDiagnostics, format, input, streams)
+	}
+
+	return program, input, EXIT_OK
+}
+
+func reportDiagnostics(reported []diagnostics.Diagnostic, format string, source string, streams Streams) int {
+	switch format {
+	case "text":
+		fmt.Fprint(streams.Err, diagnostics.RenderText(reported))
+
+	case "pretty":
+		fmt.Fprint(streams.Err, diagnostics.RenderSnippet(reported, source))
+
+	case "json":
+		content, err := diagnostics.RenderJSON(reported)
+
+		if err != nil {
+			fmt.Fprintln(streams.Err, err)
+			return EXIT_FAILURE
+		}
+
+		fmt.Fprintln(streams.Err, content)
+	}
+
+	return EXIT_FAILURE
+}
+
+// checkDiagnosticsFormat rejects an unknown format before the script runs,
+// rather than once there is something to report.
+func checkDiagnosticsFormat(format string, streams Streams) int {
+	switch format {
+	case "text", "pretty", "json":
+		return EXIT_OK
+	}
+
+	fmt.Fprintf(streams.Err, "unknown diagnostics format %q\n", format)
+
+	return EXIT_USAGE
+}
+
+// openFile lexes the file named by args while reading it, "-" standing for
+// the standard input. The bytes read are copied to source, for diagnostics
+// to quote the offending lines.
+func openFile(args []string, source io.Writer, streams Streams) (*lexer.Lexer, func(), int) {
+	if len(args) != 1 {
+		fmt.Fprint(streams.Err, USAGE)
+		return nil, nil, EXIT_USAGE
+	}
+
+	if args[0] == "-" {
+		return lexer.NewWithReader("<stdin>", io.TeeReader(streams.In, source)), func() {}, EXIT_OK
+	}
+
+	file, err := os.Open(args[0])
+
+	if err != nil {
+		fmt.Fprintln(streams.Err, err)
+		return nil, nil, EXIT_FAILURE
+	}
+
+	return lexer.NewWithReader(args[0], io.TeeReader(file, source)), func() { file.Close() }, EXIT_OK
+}
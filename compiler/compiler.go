@@ -0,0 +1,939 @@
+package compiler
+
+import (
+	"compiler/ast"
+	"compiler/object"
+	"compiler/token"
+	"fmt"
+	"strings"
+)
+
+type Bytecode struct {
+	Instructions Instructions
+	Constants    []object.Object
+	Positions    map[int]token.Span
+}
+
+type compilationScope struct {
+	instructions Instructions
+	positions    map[int]token.Span
+	loops        []*loopContext
+	tries        []*tryContext
+}
+
+// loopContext collects the jumps of break and continue statements until the
+// loop knows where they land; tryDepth counts the handlers active around it.
+type loopContext struct {
+	breakPositions    []int
+	continuePositions []int
+	tryDepth          int
+}
+
+// tryContext is a handler active while compiling; a jump out of it has to
+// remove the handler and run the finally block on the way.
+type tryContext struct {
+	finally *ast.BlockStatement
+}
+
+type Compiler struct {
+	constants   []object.Object
+	symbolTable *SymbolTable
+	scopes      []compilationScope
+	scopeIndex  int
+	// operandError is the first operand emit could not encode; Compile
+	// reports it once the program is compiled
+	operandError error
+}
+
+func New() *Compiler {
+	return NewWithState(NewSymbolTable(), []object.Object{})
+}
+
+func NewWithState(symbolTable *SymbolTable, constants []object.Object) *Compiler {
+	return &Compiler{
+		constants:   constants,
+		symbolTable: symbolTable,
+		scopes:      []compilationScope{newCompilationScope()},
+	}
+}
+
+func (compiler *Compiler) Compile(node ast.Node) error {
+	switch node := node.(type) {
+
+	case *ast.Program:
+		compiler.predeclare(node.Statements)
+
+		if err := compiler.compileStatements(node.Statements); err != nil {
+			return err
+		}
+
+		compiler.emit(OpReturnValue)
+
+		if compiler.operandError != nil {
+			return compiler.operandError
+		}
+
+	case *ast.BlockStatement:
+		compiler.symbolTable = NewBlockSymbolTable(compiler.symbolTable)
+		err := compiler.compileStatements(node.Statements)
+		compiler.symbolTable = compiler.symbolTable.Outer
+
+		return err
+
+	case *ast.ExpressionStatement:
+		if err := compiler.Compile(node.Expression); err != nil {
+			return err
+		}
+
+		compiler.emit(OpPop)
+
+	case *ast.IntegerLiteral:
+		compiler.emit(OpConstant, compiler.addConstant(&object.Integer{Value: node.Value}))
+
+	case *ast.BigIntLiteral:
+		compiler.emit(OpConstant, compiler.addConstant(&object.BigInt{Value: node.Value}))
+
+	case *ast.FloatLiteral:
+		compiler.emit(OpConstant, compiler.addConstant(&object.Float{Value: node.Value}))
+
+	case *ast.StringLiteral:
+		compiler.emit(OpConstant, compiler.addConstant(&object.String{Value: node.Value}))
+
+	case *ast.Boolean:
+		if node.Value {
+			compiler.emit(OpTrue)
+		} else {
+			compiler.emit(OpFalse)
+		}
+
+	case *ast.FunctionLiteral:
+		return compiler.compileFunctionLiteral(node, node.Name != "")
+
+	case *ast.CallExpression:
+		if err := compiler.Compile(node.Function); err != nil {
+			return err
+		}
+
+		for _, argument := range node.Arguments {
+			if err := compiler.Compile(argument); err != nil {
+				return err
+			}
+		}
+
+		compiler.emitAt(node, OpCall, len(node.Arguments))
+
+	case *ast.LetStatement:
+		if function, ok := node.Value.(*ast.FunctionLiteral); ok && node.Name != nil && !node.IsConstant() {
+			return compiler.compileFunctionDeclaration(node, function)
+		}
+
+		if err := compiler.Compile(node.Value); err != nil {
+			return err
+		}
+
+		if node.Name == nil {
+			compiler.emit(OpPop)
+			return nil
+		}
+
+		existing, redeclared := compiler.symbolTable.Declared(node.Name.Value)
+
+		if redeclared && existing.Constant {
+			return fmt.Errorf("%s: can not redeclare constant %s", node.Name.GetSpan().Start.ToString(), node.Name.Value)
+		}
+
+		var symbol Symbol
+
+		if node.IsConstant() {
+			symbol = compiler.symbolTable.DefineConstant(node.Name.Value)
+		} else {
+			symbol = compiler.symbolTable.Define(node.Name.Value)
+		}
+
+		// a redeclaration overwrites the binding that closures may have captured
+		if redeclared {
+			return compiler.assignSymbol(symbol)
+		}
+
+		compiler.storeSymbol(symbol)
+
+	case *ast.AssignExpression:
+		return compiler.compileAssignExpression(node)
+
+	case *ast.Identifier:
+		symbol, exist := compiler.symbolTable.Resolve(node.Value)
+
+		if !exist {
+			compiler.emitAt(node, OpGetBuiltin, compiler.addConstant(&object.String{Value: node.Value}))
+			return nil
+		}
+
+		// a function may run before the declaration of the global it reads
+		if symbol.Scope == GLOBAL_SCOPE && compiler.symbolTable.isPredeclared(symbol) {
+			compiler.emitAt(node, OpGetPredeclared, symbol.Index, compiler.addConstant(&object.String{Value: node.Value}))
+			return nil
+		}
+
+		compiler.loadSymbol(symbol)
+
+	case *ast.PrefixExpression:
+		opcode, exist := prefixOpcodes[node.Operator]
+
+		if !exist {
+			return fmt.Errorf("unknown prefix operator %s", node.Operator)
+		}
+
+		if err := compiler.Compile(node.Right); err != nil {
+			return err
+		}
+
+		compiler.emitAt(node, opcode)
+
+	case *ast.InfixExpression:
+		if node.Operator == "&&" || node.Operator == "||" {
+			return compiler.compileLogicalExpression(node)
+		}
+
+		opcode, exist := infixOpcodes[node.Operator]
+
+		if !exist {
+			return fmt.Errorf("unknown infix operator %s", node.Operator)
+		}
+
+		if err := compiler.Compile(node.Left); err != nil {
+			return err
+		}
+
+		if err := compiler.Compile(node.Right); err != nil {
+			return err
+		}
+
+		compiler.emitAt(node, opcode)
+
+	case *ast.IfExpression:
+		return compiler.compileIfExpression(node)
+
+	case *ast.ArrayLiteral:
+		for _, element := range node.Elements {
+			if err := compiler.Compile(element); err != nil {
+				return err
+			}
+		}
+
+		compiler.emit(OpArray, len(node.Elements))
+
+	case *ast.HashLiteral:
+		for _, pair := range node.Pairs {
+			if err := compiler.Compile(pair.Key); err != nil {
+				return err
+			}
+
+			if err := compiler.Compile(pair.Value); err != nil {
+				return err
+			}
+		}
+
+		// the VM builds the hash in one instruction, so an unusable key is
+		// reported at the literal, where the evaluator points at the key
+		compiler.emitAt(node, OpHash, len(node.Pairs)*2)
+
+	case *ast.IndexExpression:
+		if err := compiler.Compile(node.Left); err != nil {
+			return err
+		}
+
+		if err := compiler.Compile(node.Index); err != nil {
+			return err
+		}
+
+		compiler.emitAt(node, OpIndex)
+
+	case *ast.WhileStatement:
+		return compiler.compileWhileStatement(node)
+
+	case *ast.ForStatement:
+		return compiler.compileForStatement(node)
+
+	case *ast.ForInStatement:
+		return compiler.compileForInStatement(node)
+
+	case *ast.BreakStatement, *ast.ContinueStatement:
+		loops := compiler.scopes[compiler.scopeIndex].loops
+
+		if len(loops) == 0 {
+			return fmt.Errorf("%s outside of a loop", node.GetTokenLiteral())
+		}
+
+		loop := loops[len(loops)-1]
+
+		if err := compiler.unwindTries(loop.tryDepth); err != nil {
+			return err
+		}
+
+		position := compiler.emit(OpJump, 0)
+
+		if _, ok := node.(*ast.BreakStatement); ok {
+			loop.breakPositions = append(loop.breakPositions, position)
+		} else {
+			loop.continuePositions = append(loop.continuePositions, position)
+		}
+
+	case *ast.ReturnStatement:
+		if err := compiler.Compile(node.Value); err != nil {
+			return err
+		}
+
+		if err := compiler.unwindTries(0); err != nil {
+			return err
+		}
+
+		compiler.emit(OpReturnValue)
+
+	case *ast.TryExpression:
+		return compiler.compileTryExpression(node)
+
+	case *ast.ThrowStatement:
+		if err := compiler.Compile(node.Value); err != nil {
+			return err
+		}
+
+		compiler.emitAt(node, OpThrow)
+
+	case nil:
+		compiler.emit(OpNull)
+
+	default:
+		return fmt.Errorf("can not compile %T", node)
+	}
+
+	return nil
+}
+
+func (compiler *Compiler) Bytecode() *Bytecode {
+	return &Bytecode{
+		Instructions: compiler.currentInstructions(),
+		Constants:    compiler.constants,
+		Positions:    compiler.scopes[compiler.scopeIndex].positions,
+	}
+}
+
+func (compiler *Compiler) SymbolTable() *SymbolTable {
+	return compiler.symbolTable
+}
+
+// compileStatements leaves exactly one value on the stack: the value of the
+// last expression statement, or null, the same way evalBlockStatements does.
+func (compiler *Compiler) compileStatements(statements []ast.Statement) error {
+	if len(statements) == 0 {
+		compiler.emit(OpNull)
+		return nil
+	}
+
+	for index, statement := range statements {
+		isLast := index == len(statements)-1
+
+		if expressionStatement, ok := statement.(*ast.ExpressionStatement); ok && isLast {
+			return compiler.Compile(expressionStatement.Expression)
+		}
+
+		if err := compiler.Compile(statement); err != nil {
+			return err
+		}
+
+		if isLast {
+			compiler.emit(OpNull)
+		}
+	}
+
+	return nil
+}
+
+func (compiler *Compiler) compileIfExpression(node *ast.IfExpression) error {
+	if err := compiler.Compile(node.Condition); err != nil {
+		return err
+	}
+
+	jumpNotTruthyPosition := compiler.emit(OpJumpNotTruthy, 0)
+
+	if err := compiler.Compile(node.Consequence); err != nil {
+		return err
+	}
+
+	jumpPosition := compiler.emit(OpJump, 0)
+	compiler.changeOperand(jumpNotTruthyPosition, len(compiler.currentInstructions()))
+
+	if node.Alternative != nil {
+		if err := compiler.Compile(node.Alternative); err != nil {
+			return err
+		}
+	} else {
+		compiler.emit(OpNull)
+	}
+
+	compiler.changeOperand(jumpPosition, len(compiler.currentInstructions()))
+
+	return nil
+}
+
+// compileLogicalExpression jumps over the right operand when the left one
+// already decides the result, leaving a boolean the way the evaluator does.
+func (compiler *Compiler) compileLogicalExpression(node *ast.InfixExpression) error {
+	if err := compiler.Compile(node.Left); err != nil {
+		return err
+	}
+
+	falseJumpPositions := []int{}
+	endJumpPositions := []int{}
+
+	if node.Operator == "&&" {
+		falseJumpPositions = append(falseJumpPositions, compiler.emit(OpJumpNotTruthy, 0))
+	} else {
+		rightJumpPosition := compiler.emit(OpJumpNotTruthy, 0)
+		compiler.emit(OpTrue)
+		endJumpPositions = append(endJumpPositions, compiler.emit(OpJump, 0))
+		compiler.changeOperand(rightJumpPosition, len(compiler.currentInstructions()))
+	}
+
+	if err := compiler.Compile(node.Right); err != nil {
+		return err
+	}
+
+	falseJumpPositions = append(falseJumpPositions, compiler.emit(OpJumpNotTruthy, 0))
+	compiler.emit(OpTrue)
+	endJumpPositions = append(endJumpPositions, compiler.emit(OpJump, 0))
+
+	for _, position := range falseJumpPositions {
+		compiler.changeOperand(position, len(compiler.currentInstructions()))
+	}
+
+	compiler.emit(OpFalse)
+
+	for _, position := range endJumpPositions {
+		compiler.changeOperand(position, len(compiler.currentInstructions()))
+	}
+
+	return nil
+}
+
+func (compiler *Compiler) compileWhileStatement(node *ast.WhileStatement) error {
+	startPosition := len(compiler.currentInstructions())
+
+	if err := compiler.Compile(node.Condition); err != nil {
+		return err
+	}
+
+	exitPosition := compiler.emit(OpJumpNotTruthy, 0)
+
+	if err := compiler.compileLoopBody(node.Body); err != nil {
+		return err
+	}
+
+	compiler.emit(OpJump, startPosition)
+	compiler.changeOperand(exitPosition, len(compiler.currentInstructions()))
+	compiler.leaveLoop(startPosition)
+
+	return nil
+}
+
+func (compiler *Compiler) compileForStatement(node *ast.ForStatement) error {
+	compiler.symbolTable = NewBlockSymbolTable(compiler.symbolTable)
+	defer func() { compiler.symbolTable = compiler.symbolTable.Outer }()
+
+	if node.Init != nil {
+		if err := compiler.Compile(node.Init); err != nil {
+			return err
+		}
+	}
+
+	startPosition := len(compiler.currentInstructions())
+	exitPosition := -1
+
+	if node.Condition != nil {
+		if err := compiler.Compile(node.Condition); err != nil {
+			return err
+		}
+
+		exitPosition = compiler.emit(OpJumpNotTruthy, 0)
+	}
+
+	if err := compiler.compileLoopBody(node.Body); err != nil {
+		return err
+	}
+
+	continuePosition := len(compiler.currentInstructions())
+
+	if node.Update != nil {
+		if err := compiler.Compile(node.Update); err != nil {
+			return err
+		}
+	}
+
+	compiler.emit(OpJump, startPosition)
+
+	if exitPosition >= 0 {
+		compiler.changeOperand(exitPosition, len(compiler.currentInstructions()))
+	}
+
+	compiler.leaveLoop(continuePosition)
+
+	return nil
+}
+
+// compileForInStatement walks the array with two hidden bindings, named so
+// that no identifier of the language can clash with them.
+func (compiler *Compiler) compileForInStatement(node *ast.ForInStatement) error {
+	compiler.symbolTable = NewBlockSymbolTable(compiler.symbolTable)
+	defer func() { compiler.symbolTable = compiler.symbolTable.Outer }()
+
+	if err := compiler.Compile(node.Iterable); err != nil {
+		return err
+	}
+
+	compiler.emitAt(node.Iterable, OpIterable)
+	array := compiler.symbolTable.Define("$array")
+	compiler.storeSymbol(array)
+
+	compiler.emit(OpConstant, compiler.addConstant(&object.Integer{Value: 0}))
+	index := compiler.symbolTable.Define("$index")
+	compiler.storeSymbol(index)
+
+	startPosition := len(compiler.currentInstructions())
+	compiler.loadSymbol(array)
+	compiler.loadSymbol(index)
+	exitPosition := compiler.emit(OpIterNext, 0)
+	compiler.storeSymbol(compiler.symbolTable.Define(node.Variable.Value))
+
+	if err := compiler.compileLoopBody(node.Body); err != nil {
+		return err
+	}
+
+	continuePosition := len(compiler.currentInstructions())
+	compiler.loadSymbol(index)
+	compiler.emit(OpConstant, compiler.addConstant(&object.Integer{Value: 1}))
+	compiler.emit(OpAdd)
+	compiler.storeSymbol(index)
+	compiler.emit(OpJump, startPosition)
+
+	compiler.changeOperand(exitPosition, len(compiler.currentInstructions()))
+	compiler.leaveLoop(continuePosition)
+
+	return nil
+}
+
+// compileLoopBody opens the loop context that break and continue report to;
+// the caller closes it with leaveLoop once the jump targets are known.
+func (compiler *Compiler) compileLoopBody(body *ast.BlockStatement) error {
+	scope := &compiler.scopes[compiler.scopeIndex]
+	scope.loops = append(scope.loops, &loopContext{tryDepth: len(scope.tries)})
+
+	if err := compiler.Compile(body); err != nil {
+		return err
+	}
+
+	compiler.emit(OpPop)
+
+	return nil
+}
+
+func (compiler *Compiler) leaveLoop(continuePosition int) {
+	scope := &compiler.scopes[compiler.scopeIndex]
+	loop := scope.loops[len(scope.loops)-1]
+	scope.loops = scope.loops[:len(scope.loops)-1]
+
+	for _, position := range loop.breakPositions {
+		compiler.changeOperand(position, len(compiler.currentInstructions()))
+	}
+
+	for _, position := range loop.continuePositions {
+		compiler.changeOperand(position, continuePosition)
+	}
+}
+
+// compileTryExpression lays the finally block out once per way of leaving
+// the try: after the block or the catch clause, and before rethrowing an
+// error that no catch clause handles.
+func (compiler *Compiler) compileTryExpression(node *ast.TryExpression) error {
+	handlerPosition := compiler.emit(OpTry, 0)
+
+	if err := compiler.compileTryBlock(node.Block, node.Finally); err != nil {
+		return err
+	}
+
+	compiler.emit(OpEndTry)
+
+	if err := compiler.compileFinally(node.Finally); err != nil {
+		return err
+	}
+
+	endPositions := []int{compiler.emit(OpJump, 0)}
+	compiler.changeOperand(handlerPosition, len(compiler.currentInstructions()))
+
+	if node.Catch != nil {
+		rethrowPosition := -1
+
+		if node.Finally != nil {
+			rethrowPosition = compiler.emit(OpTry, 0)
+		}
+
+		compiler.symbolTable = NewBlockSymbolTable(compiler.symbolTable)
+
+		if node.Parameter != nil {
+			compiler.storeSymbol(compiler.symbolTable.Define(node.Parameter.Value))
+		} else {
+			compiler.emit(OpPop)
+		}
+
+		var err error
+
+		if node.Finally != nil {
+			err = compiler.compileTryBlock(node.Catch, node.Finally)
+		} else {
+			err = compiler.Compile(node.Catch)
+		}
+
+		compiler.symbolTable = compiler.symbolTable.Outer
+
+		if err != nil {
+			return err
+		}
+
+		if node.Finally == nil {
+			compiler.changeOperand(endPositions[0], len(compiler.currentInstructions()))
+			return nil
+		}
+
+		compiler.emit(OpEndTry)
+
+		if err := compiler.compileFinally(node.Finally); err != nil {
+			return err
+		}
+
+		endPositions = append(endPositions, compiler.emit(OpJump, 0))
+		compiler.changeOperand(rethrowPosition, len(compiler.currentInstructions()))
+	}
+
+	if err := compiler.compileFinally(node.Finally); err != nil {
+		return err
+	}
+
+	compiler.emit(OpThrow)
+
+	for _, position := range endPositions {
+		compiler.changeOperand(position, len(compiler.currentInstructions()))
+	}
+
+	return nil
+}
+
+// compileTryBlock compiles a block guarded by the handler emitted before it.
+func (compiler *Compiler) compileTryBlock(block *ast.BlockStatement, finally *ast.BlockStatement) error {
+	scope := &compiler.scopes[compiler.scopeIndex]
+	scope.tries = append(scope.tries, &tryContext{finally: finally})
+
+	err := compiler.Compile(block)
+
+	scope = &compiler.scopes[compiler.scopeIndex]
+	scope.tries = scope.tries[:len(scope.tries)-1]
+
+	return err
+}
+
+func (compiler *Compiler) compileFinally(finally *ast.BlockStatement) error {
+	if finally == nil {
+		return nil
+	}
+
+	if err := compiler.Compile(finally); err != nil {
+		return err
+	}
+
+	compiler.emit(OpPop)
+
+	return nil
+}
+
+// unwindTries removes the handlers above depth before a jump out of them,
+// running their finally blocks from the innermost out. A finally block is
+// compiled without its own handler, so a jump inside it does not run it again.
+func (compiler *Compiler) unwindTries(depth int) error {
+	tries := compiler.scopes[compiler.scopeIndex].tries
+
+	defer func() { compiler.scopes[compiler.scopeIndex].tries = tries }()
+
+	for index := len(tries) - 1; index >= depth; index-- {
+		compiler.emit(OpEndTry)
+		compiler.scopes[compiler.scopeIndex].tries = tries[:index:index]
+
+		if err := compiler.compileFinally(tries[index].finally); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// predeclare defines the names a program declares at the top level before
+// compiling it, so that a function can call one declared after it, as the
+// evaluator, which looks names up when the call runs, allows.
+func (compiler *Compiler) predeclare(statements []ast.Statement) {
+	for _, statement := range statements {
+		if let, ok := statement.(*ast.LetStatement); ok && let.Name != nil {
+			compiler.symbolTable.Predeclare(let.Name.Value, let.IsConstant())
+		}
+	}
+}
+
+// compileFunctionDeclaration binds a function to a mutable name that the
+// body reads through the binding, as the evaluator does, so a reassignment
+// of the name is seen inside the function too.
+func (compiler *Compiler) compileFunctionDeclaration(node *ast.LetStatement, function *ast.FunctionLiteral) error {
+	existing, redeclared := compiler.symbolTable.Declared(node.Name.Value)
+
+	if redeclared && existing.Constant {
+		return fmt.Errorf("%s: can not redeclare constant %s", node.Name.GetSpan().Start.ToString(), node.Name.Value)
+	}
+
+	symbol := compiler.symbolTable.Define(node.Name.Value)
+
+	// a fresh binding for the function to capture, rather than one that a
+	// closure made by an earlier run of the same block holds
+	if !redeclared {
+		compiler.emit(OpNull)
+		compiler.storeSymbol(symbol)
+	}
+
+	if err := compiler.compileFunctionLiteral(function, false); err != nil {
+		return err
+	}
+
+	return compiler.assignSymbol(symbol)
+}
+
+// compileFunctionLiteral binds the function name to the closure itself
+// when bindsName is set, which suits names that can not be reassigned.
+func (compiler *Compiler) compileFunctionLiteral(node *ast.FunctionLiteral, bindsName bool) error {
+	compiler.enterScope()
+
+	if bindsName {
+		compiler.symbolTable.DefineFunctionName(node.Name)
+	}
+
+	for _, parameter := range node.Parameters {
+		compiler.symbolTable.Define(parameter.Value)
+	}
+
+	if err := compiler.compileStatements(node.Body.Statements); err != nil {
+		compiler.leaveScope()
+		return err
+	}
+
+	compiler.emit(OpReturnValue)
+
+	freeSymbols := compiler.symbolTable.FreeSymbols
+	numLocals := compiler.symbolTable.NumDefinitions()
+	instructions, positions := compiler.leaveScope()
+
+	for _, symbol := range freeSymbols {
+		compiler.captureSymbol(symbol)
+	}
+
+	function := &object.CompiledFunction{
+		Instructions:  instructions,
+		NumLocals:     numLocals,
+		NumParameters: len(node.Parameters),
+		Name:          node.Name,
+		Positions:     positions,
+	}
+
+	compiler.emit(OpClosure, compiler.addConstant(function), len(freeSymbols))
+
+	return nil
+}
+
+func (compiler *Compiler) compileAssignExpression(node *ast.AssignExpression) error {
+	var operator Opcode
+
+	if node.Operator != "=" {
+		opcode, exist := infixOpcodes[strings.TrimSuffix(node.Operator, "=")]
+
+		if !exist {
+			return fmt.Errorf("unknown operator %s", node.Operator)
+		}
+
+		operator = opcode
+	}
+
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		symbol, exist := compiler.symbolTable.Resolve(target.Value)
+
+		if !exist {
+			return fmt.Errorf("assignment to undeclared variable %s", target.Value)
+		}
+
+		if symbol.Constant {
+			return fmt.Errorf("%s: can not reassign constant %s", target.GetSpan().Start.ToString(), target.Value)
+		}
+
+		if operator != 0 {
+			compiler.loadSymbol(symbol)
+		}
+
+		if err := compiler.Compile(node.Value); err != nil {
+			return err
+		}
+
+		if operator != 0 {
+			compiler.emitAt(node, operator)
+		}
+
+		if err := compiler.assignSymbol(symbol); err != nil {
+			return err
+		}
+
+		compiler.loadSymbol(symbol)
+
+	case *ast.IndexExpression:
+		if err := compiler.Compile(target.Left); err != nil {
+			return err
+		}
+
+		if err := compiler.Compile(target.Index); err != nil {
+			return err
+		}
+
+		if err := compiler.Compile(node.Value); err != nil {
+			return err
+		}
+
+		compiler.emitAt(node, OpSetIndex, int(operator))
+
+	default:
+		return fmt.Errorf("can not assign to %s", node.Target.ToString())
+	}
+
+	return nil
+}
+
+// storeSymbol binds a fresh value to a symbol, replacing whatever a previous
+// run of the same code left in its slot.
+func (compiler *Compiler) storeSymbol(symbol Symbol) {
+	if symbol.Scope == GLOBAL_SCOPE {
+		compiler.emit(OpSetGlobal, symbol.Index)
+	} else {
+		compiler.emit(OpSetLocal, symbol.Index)
+	}
+}
+
+// assignSymbol writes through to the binding, which closures may share.
+func (compiler *Compiler) assignSymbol(symbol Symbol) error {
+	switch symbol.Scope {
+	case GLOBAL_SCOPE:
+		compiler.emit(OpAssignGlobal, symbol.Index)
+
+	case LOCAL_SCOPE:
+		compiler.emit(OpAssignLocal, symbol.Index)
+
+	case FREE_SCOPE:
+		compiler.emit(OpAssignFree, symbol.Index)
+
+	default:
+		return fmt.Errorf("can not assign to function %s inside its own body", symbol.Name)
+	}
+
+	return nil
+}
+
+// captureSymbol pushes the binding itself rather than its value, for a
+// closure to share it.
+func (compiler *Compiler) captureSymbol(symbol Symbol) {
+	switch symbol.Scope {
+	case GLOBAL_SCOPE:
+		compiler.emit(OpCaptureGlobal, symbol.Index)
+
+	case LOCAL_SCOPE:
+		compiler.emit(OpCaptureLocal, symbol.Index)
+
+	case FREE_SCOPE:
+		compiler.emit(OpCaptureFree, symbol.Index)
+
+	default:
+		compiler.loadSymbol(symbol)
+	}
+}
+
+func (compiler *Compiler) loadSymbol(symbol Symbol) {
+	switch symbol.Scope {
+	case GLOBAL_SCOPE:
+		compiler.emit(OpGetGlobal, symbol.Index)
+
+	case LOCAL_SCOPE:
+		compiler.emit(OpGetLocal, symbol.Index)
+
+	case FREE_SCOPE:
+		compiler.emit(OpGetFree, symbol.Index)
+
+	case FUNCTION_SCOPE:
+		compiler.emit(OpCurrentClosure)
+	}
+}
+
+func (compiler *Compiler) addConstant(constant object.Object) int {
+	compiler.constants = append(compiler.constants, constant)
+
+	return len(compiler.constants) - 1
+}
+
+func (compiler *Compiler) emit(opcode Opcode, operands ...int) int {
+	compiler.checkOperands(opcode, operands...)
+	position := len(compiler.currentInstructions())
+	compiler.scopes[compiler.scopeIndex].instructions = append(compiler.currentInstructions(), Make(opcode, operands...)...)
+
+	return position
+}
+
+// emitAt emits an instruction that can raise an error, recording the node
+// it was compiled from for the VM to locate the error.
+func (compiler *Compiler) emitAt(node ast.Node, opcode Opcode, operands ...int) int {
+	position := compiler.emit(opcode, operands...)
+	compiler.scopes[compiler.scopeIndex].positions[position] = node.GetSpan()
+
+	return position
+}
+
+func (compiler *Compiler) changeOperand(position int, operand int) {
+	opcode := Opcode(compiler.currentInstructions()[position])
+	compiler.checkOperands(opcode, operand)
+	copy(compiler.currentInstructions()[position:], Make(opcode, operand))
+}
+
+func (compiler *Compiler) checkOperands(opcode Opcode, operands ...int) {
+	if err := CheckOperands(opcode, operands...); err != nil && compiler.operandError == nil {
+		compiler.operandError = err
+	}
+}
+
+func (compiler *Compiler) currentInstructions() Instructions {
+	return compiler.scopes[compiler.scopeIndex].instructions
+}
+
+func (compiler *Compiler) enterScope() {
+	compiler.scopes = append(compiler.scopes, newCompilationScope())
+	compiler.scopeIndex++
+	compiler.symbolTable = NewEnclosedSymbolTable(compiler.symbolTable)
+}
+
+func (compiler *Compiler) leaveScope() (Instructions, map[int]token.Span) {
+	instructions := compiler.currentInstructions()
+	positions := compiler.scopes[compiler.scopeIndex].positions
+
+	compiler.scopes = compiler.scopes[:len(compiler.scopes)-1]
+	compiler.scopeIndex--
+	compiler.symbolTable = compiler.symbolTable.Outer
+
+	return instructions, positions
+}
+
+func newCompilationScope() compilationScope {
+	return compilationScope{instructions: Instructions{}, positions: map[int]token.Span{}}
+}
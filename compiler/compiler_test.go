@@ -0,0 +1,35 @@
+package compiler
+
+import (
+	"compiler/lexer"
+	"compiler/parser"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestOperandOverflow(t *testing.T) {
+	var locals, parameters, arguments strings.Builder
+
+	for index := 0; index < 300; index++ {
+		fmt.Fprintf(&locals, "let v%d = %d; ", index, index)
+	}
+
+	for index := 0; index < 260; index++ {
+		fmt.Fprintf(&parameters, "a%d, ", index)
+		fmt.Fprintf(&arguments, "%d, ", index)
+	}
+
+	inputs := []string{
+		"let f = fn() { " + locals.String() + "[v0, v256, v299] }; f()",
+		"let f = fn(" + strings.TrimSuffix(parameters.String(), ", ") + ") { a0 }; f(" + strings.TrimSuffix(arguments.String(), ", ") + ")",
+	}
+
+	for _, input := range inputs {
+		program := parser.New(lexer.New(input)).ParseProgram()
+
+		if err := New().Compile(program); err == nil || !strings.Contains(err.Error(), "program too large") {
+			t.Errorf("expected an operand overflow error, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package compiler
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+type Opcode byte
+
+type Instructions []byte
+
+const (
+	OpConstant Opcode = iota
+	OpPop
+
+	OpTrue
+	OpFalse
+	OpNull
+
+	// math operators
+	OpAdd
+	OpSub
+	OpMul
+	OpDiv
+	OpMod
+	OpEqual
+	OpNotEqual
+	OpGreaterThan
+	OpLessThan
+	OpGreaterEqual
+	OpLessEqual
+	OpBitAnd
+	OpBitOr
+	OpBitXor
+	OpShiftLeft
+	OpShiftRight
+	OpMinus
+	OpBang
+
+	// collections
+	OpArray
+	OpHash
+	OpIndex
+	OpSetIndex
+
+	// control flow
+	OpJump
+	OpJumpNotTruthy
+	OpIterable
+	OpIterNext
+	OpCall
+	OpReturnValue
+	OpTry
+	OpEndTry
+	OpThrow
+
+	// bindings
+	OpGetGlobal
+	OpSetGlobal
+	OpAssignGlobal
+	OpCaptureGlobal
+	OpGetLocal
+	OpSetLocal
+	OpAssignLocal
+	OpCaptureLocal
+	OpGetFree
+	OpAssignFree
+	OpCaptureFree
+	OpGetBuiltin
+	OpGetPredeclared
+	OpClosure
+	OpCurrentClosure
+)
+
+type Definition struct {
+	Name          string
+	OperandWidths []int
+}
+
+var definitions = map[Opcode]*Definition{
+	OpConstant: {"OpConstant", []int{2}},
+	OpPop:      {"OpPop", []int{}},
+
+	OpTrue:  {"OpTrue", []int{}},
+	OpFalse: {"OpFalse", []int{}},
+	OpNull:  {"OpNull", []int{}},
+
+	OpAdd:          {"OpAdd", []int{}},
+	OpSub:          {"OpSub", []int{}},
+	OpMul:          {"OpMul", []int{}},
+	OpDiv:          {"OpDiv", []int{}},
+	OpMod:          {"OpMod", []int{}},
+	OpEqual:        {"OpEqual", []int{}},
+	OpNotEqual:     {"OpNotEqual", []int{}},
+	OpGreaterThan:  {"OpGreaterThan", []int{}},
+	OpLessThan:     {"OpLessThan", []int{}},
+	OpGreaterEqual: {"OpGreaterEqual", []int{}},
+	OpLessEqual:    {"OpLessEqual", []int{}},
+	OpBitAnd:       {"OpBitAnd", []int{}},
+	OpBitOr:        {"OpBitOr", []int{}},
+	OpBitXor:       {"OpBitXor", []int{}},
+	OpShiftLeft:    {"OpShiftLeft", []int{}},
+	OpShiftRight:   {"OpShiftRight", []int{}},
+	OpMinus:        {"OpMinus", []int{}},
+	OpBang:         {"OpBang", []int{}},
+
+	OpArray: {"OpArray", []int{2}},
+	OpHash:  {"OpHash", []int{2}},
+	OpIndex: {"OpIndex", []int{}},
+	// the operand is the operator opcode of a compound assignment, 0 for =
+	OpSetIndex: {"OpSetIndex", []int{1}},
+
+	OpJump:          {"OpJump", []int{2}},
+	OpJumpNotTruthy: {"OpJumpNotTruthy", []int{2}},
+	OpIterable:      {"OpIterable", []int{}},
+	OpIterNext:      {"OpIterNext", []int{2}},
+	OpCall:          {"OpCall", []int{1}},
+	OpReturnValue:   {"OpReturnValue", []int{}},
+	// the operand is the address of the handler that catches errors
+	OpTry:    {"OpTry", []int{2}},
+	OpEndTry: {"OpEndTry", []int{}},
+	OpThrow:  {"OpThrow", []int{}},
+
+	OpGetGlobal:      {"OpGetGlobal", []int{2}},
+	OpSetGlobal:      {"OpSetGlobal", []int{2}},
+	OpAssignGlobal:   {"OpAssignGlobal", []int{2}},
+	OpCaptureGlobal:  {"OpCaptureGlobal", []int{2}},
+	OpGetLocal:       {"OpGetLocal", []int{1}},
+	OpSetLocal:       {"OpSetLocal", []int{1}},
+	OpAssignLocal:    {"OpAssignLocal", []int{1}},
+	OpCaptureLocal:   {"OpCaptureLocal", []int{1}},
+	OpGetFree:        {"OpGetFree", []int{1}},
+	OpAssignFree:     {"OpAssignFree", []int{1}},
+	OpCaptureFree:    {"OpCaptureFree", []int{1}},
+	OpGetBuiltin:     {"OpGetBuiltin", []int{2}},
+	OpClosure:        {"OpClosure", []int{2, 1}},
+	OpCurrentClosure: {"OpCurrentClosure", []int{}},
+
+	// the operands are the global and the constant holding its name
+	OpGetPredeclared: {"OpGetPredeclared", []int{2, 2}},
+}
+
+var infixOperators = map[Opcode]string{
+	OpAdd:          "+",
+	OpSub:          "-",
+	OpMul:          "*",
+	OpDiv:          "/",
+	OpMod:          "%",
+	OpEqual:        "==",
+	OpNotEqual:     "!=",
+	OpGreaterThan:  ">",
+	OpLessThan:     "<",
+	OpGreaterEqual: ">=",
+	OpLessEqual:    "<=",
+	OpBitAnd:       "&",
+	OpBitOr:        "|",
+	OpBitXor:       "^",
+	OpShiftLeft:    "<<",
+	OpShiftRight:   ">>",
+}
+
+var prefixOperators = map[Opcode]string{
+	OpMinus: "-",
+	OpBang:  "!",
+}
+
+var (
+	infixOpcodes  = invertOperators(infixOperators)
+	prefixOpcodes = invertOperators(prefixOperators)
+)
+
+func invertOperators(operators map[Opcode]string) map[string]Opcode {
+	opcodes := make(map[string]Opcode, len(operators))
+
+	for opcode, operator := range operators {
+		opcodes[operator] = opcode
+	}
+
+	return opcodes
+}
+
+func Lookup(opcode Opcode) (*Definition, error) {
+	definition, exist := definitions[opcode]
+
+	if !exist {
+		return nil, fmt.Errorf("opcode %d undefined", opcode)
+	}
+
+	return definition, nil
+}
+
+// InfixOperator returns the source operator an arithmetic or comparison
+// opcode was compiled from, so the VM can share the evaluator semantics.
+func InfixOperator(opcode Opcode) (string, bool) {
+	operator, exist := infixOperators[opcode]
+
+	return operator, exist
+}
+
+func PrefixOperator(opcode Opcode) (string, bool) {
+	operator, exist := prefixOperators[opcode]
+
+	return operator, exist
+}
+
+func Make(opcode Opcode, operands ...int) []byte {
+	definition, exist := definitions[opcode]
+
+	if !exist {
+		return []byte{}
+	}
+
+	instructionLength := 1
+
+	for _, width := range definition.OperandWidths {
+		instructionLength += width
+	}
+
+	instruction := make([]byte, instructionLength)
+	instruction[0] = byte(opcode)
+	offset := 1
+
+	for index, operand := range operands {
+		width := definition.OperandWidths[index]
+
+		switch width {
+		case 2:
+			binary.BigEndian.PutUint16(instruction[offset:], uint16(operand))
+		case 1:
+			instruction[offset] = byte(operand)
+		}
+
+		offset += width
+	}
+
+	return instruction
+}
+
+// CheckOperands reports an operand too large for its width in the
+// instruction, which Make would otherwise truncate.
+func CheckOperands(opcode Opcode, operands ...int) error {
+	definition, err := Lookup(opcode)
+
+	if err != nil {
+		return err
+	}
+
+	for index, operand := range operands {
+		width := definition.OperandWidths[index]
+
+		if limit := 1<<(8*width) - 1; operand < 0 || operand > limit {
+			return fmt.Errorf("program too large: %s operand %d exceeds the limit of %d", definition.Name, operand, limit)
+		}
+	}
+
+	return nil
+}
+
+func ReadOperands(definition *Definition, instructions Instructions) ([]int, int) {
+	operands := make([]int, len(definition.OperandWidths))
+	offset := 0
+
+	for index, width := range definition.OperandWidths {
+		switch width {
+		case 2:
+			operands[index] = int(ReadUint16(instructions[offset:]))
+		case 1:
+			operands[index] = int(ReadUint8(instructions[offset:]))
+		}
+
+		offset += width
+	}
+
+	return operands, offset
+}
+
+func ReadUint16(instructions Instructions) uint16 {
+	return binary.BigEndian.Uint16(instructions)
+}
+
+func ReadUint8(instructions Instructions) uint8 {
+	return uint8(instructions[0])
+}
+
+func (instructions Instructions) ToString() string {
+	var output bytes.Buffer
+
+	for offset := 0; offset < len(instructions); {
+		definition, err := Lookup(Opcode(instructions[offset]))
+
+		if err != nil {
+			fmt.Fprintf(&output, "ERROR: %s\n", err)
+			offset++
+			continue
+		}
+
+		operands, read := ReadOperands(definition, instructions[offset+1:])
+		fmt.Fprintf(&output, "%04d %s", offset, definition.Name)
+
+		for _, operand := range operands {
+			fmt.Fprintf(&output, " %d", operand)
+		}
+
+		output.WriteString("\n")
+		offset += 1 + read
+	}
+
+	return output.String()
+}
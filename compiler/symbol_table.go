@@ -0,0 +1,179 @@
+package compiler
+
+type SymbolScope string
+
+const (
+	GLOBAL_SCOPE   SymbolScope = "GLOBAL"
+	LOCAL_SCOPE    SymbolScope = "LOCAL"
+	FREE_SCOPE     SymbolScope = "FREE"
+	FUNCTION_SCOPE SymbolScope = "FUNCTION"
+)
+
+type Symbol struct {
+	Name     string
+	Scope    SymbolScope
+	Index    int
+	Constant bool
+}
+
+type SymbolTable struct {
+	Outer       *SymbolTable
+	FreeSymbols []Symbol
+
+	store map[string]Symbol
+	// owner allocates the slots: the table itself for the global and
+	// function scopes, the enclosing function table for a block scope
+	owner            *SymbolTable
+	definitionsCount int
+	// predeclared holds the top-level names that functions may refer to
+	// before their declaration has run
+	predeclared map[string]bool
+}
+
+func NewSymbolTable() *SymbolTable {
+	symbolTable := &SymbolTable{
+		store:       make(map[string]Symbol),
+		FreeSymbols: []Symbol{},
+		predeclared: make(map[string]bool),
+	}
+	symbolTable.owner = symbolTable
+
+	return symbolTable
+}
+
+func NewEnclosedSymbolTable(outer *SymbolTable) *SymbolTable {
+	symbolTable := NewSymbolTable()
+	symbolTable.Outer = outer
+
+	return symbolTable
+}
+
+func NewBlockSymbolTable(outer *SymbolTable) *SymbolTable {
+	symbolTable := NewEnclosedSymbolTable(outer)
+	symbolTable.owner = outer.owner
+
+	return symbolTable
+}
+
+func (symbolTable *SymbolTable) Define(name string) Symbol {
+	// redeclaring a name in the same scope overwrites its slot, just as a
+	// second let overwrites the binding in the evaluator's environment
+	if symbol, exist := symbolTable.store[name]; exist && (symbol.Scope == GLOBAL_SCOPE || symbol.Scope == LOCAL_SCOPE) {
+		return symbol
+	}
+
+	symbol := Symbol{Name: name, Index: symbolTable.owner.definitionsCount, Scope: LOCAL_SCOPE}
+
+	if symbolTable.owner.Outer == nil {
+		symbol.Scope = GLOBAL_SCOPE
+	}
+
+	symbolTable.store[name] = symbol
+	symbolTable.owner.definitionsCount++
+
+	return symbol
+}
+
+func (symbolTable *SymbolTable) DefineConstant(name string) Symbol {
+	symbol := symbolTable.Define(name)
+	symbol.Constant = true
+	symbolTable.store[name] = symbol
+
+	return symbol
+}
+
+// DefineFunctionName binds the name of a const function, inside its body,
+// to the closure itself.
+func (symbolTable *SymbolTable) DefineFunctionName(name string) Symbol {
+	symbol := Symbol{Name: name, Index: 0, Scope: FUNCTION_SCOPE, Constant: true}
+	symbolTable.store[name] = symbol
+
+	return symbol
+}
+
+// Predeclare defines a name declared further down the same scope, for the
+// functions declared before it to refer to.
+func (symbolTable *SymbolTable) Predeclare(name string, constant bool) {
+	if _, exist := symbolTable.store[name]; exist {
+		return
+	}
+
+	if constant {
+		symbolTable.DefineConstant(name)
+	} else {
+		symbolTable.Define(name)
+	}
+
+	symbolTable.predeclared[name] = true
+}
+
+// Declared returns the binding that a declaration of name in this scope
+// redeclares; the first declaration of a predeclared name redeclares none.
+func (symbolTable *SymbolTable) Declared(name string) (Symbol, bool) {
+	if symbolTable.predeclared[name] {
+		delete(symbolTable.predeclared, name)
+		return Symbol{}, false
+	}
+
+	symbol, exist := symbolTable.store[name]
+
+	return symbol, exist && (symbol.Scope == GLOBAL_SCOPE || symbol.Scope == LOCAL_SCOPE)
+}
+
+func (symbolTable *SymbolTable) Resolve(name string) (Symbol, bool) {
+	symbol, exist := symbolTable.store[name]
+
+	if exist || symbolTable.Outer == nil {
+		return symbol, exist
+	}
+
+	symbol, exist = symbolTable.Outer.Resolve(name)
+
+	if !exist || symbolTable.isBlock() || (symbol.Scope == GLOBAL_SCOPE && symbolTable.isTopLevel(symbol)) {
+		return symbol, exist
+	}
+
+	return symbolTable.defineFree(symbol), true
+}
+
+// isTopLevel reports whether a global symbol is declared outside of any
+// block; the globals of blocks are captured like locals, since a block run
+// again by a loop declares fresh bindings.
+func (symbolTable *SymbolTable) isTopLevel(symbol Symbol) bool {
+	root := symbolTable
+
+	for root.Outer != nil {
+		root = root.Outer
+	}
+
+	return root.store[symbol.Name] == symbol
+}
+
+// isPredeclared reports whether a global symbol is predeclared and its
+// declaration has not been compiled yet.
+func (symbolTable *SymbolTable) isPredeclared(symbol Symbol) bool {
+	root := symbolTable
+
+	for root.Outer != nil {
+		root = root.Outer
+	}
+
+	return root.predeclared[symbol.Name] && root.store[symbol.Name] == symbol
+}
+
+func (symbolTable *SymbolTable) NumDefinitions() int {
+	return symbolTable.owner.definitionsCount
+}
+
+func (symbolTable *SymbolTable) isBlock() bool {
+	return symbolTable.owner != symbolTable
+}
+
+func (symbolTable *SymbolTable) defineFree(original Symbol) Symbol {
+	symbolTable.FreeSymbols = append(symbolTable.FreeSymbols, original)
+
+	symbol := Symbol{Name: original.Name, Index: len(symbolTable.FreeSymbols) - 1, Scope: FREE_SCOPE, Constant: original.Constant}
+	symbolTable.store[original.Name] = symbol
+
+	return symbol
+}
@@ -0,0 +1,69 @@
+package diagnostics
+
+import (
+	"compiler/token"
+	"sort"
+)
+
+type Severity string
+
+const (
+	ERROR   Severity = "error"
+	WARNING Severity = "warning"
+	NOTE    Severity = "note"
+)
+
+const (
+	// lexer
+	ILLEGAL_CHARACTER    = "E0001"
+	UNTERMINATED_STRING  = "E0002"
+	INVALID_ESCAPE       = "E0003"
+	UNTERMINATED_COMMENT = "E0004"
+	INVALID_NUMBER       = "E0005"
+	READ_FAILURE         = "E0006"
+
+	// parser
+	UNEXPECTED_TOKEN   = "E0100"
+	MISSING_EXPRESSION = "E0101"
+	INVALID_LITERAL    = "E0102"
+	TOO_MANY_ERRORS    = "E0103"
+	OUTSIDE_LOOP       = "E0104"
+	INVALID_ASSIGNMENT = "E0105"
+	CONTROL_IN_VALUE   = "E0106"
+
+	// evaluator
+	RUNTIME_ERROR = "E0200"
+)
+
+type Fix struct {
+	Message     string
+	Span        token.Span
+	Replacement string
+}
+
+type Diagnostic struct {
+	Severity Severity
+	Code     string
+	Span     token.Span
+	Message  string
+	Notes    []string
+	Fix      *Fix
+}
+
+func New(code string, span token.Span, message string, notes ...string) Diagnostic {
+	return Diagnostic{
+		Severity: ERROR,
+		Code:     code,
+		Span:     span,
+		Message:  message,
+		Notes:    notes,
+	}
+}
+
+// Sort orders diagnostics by their position in the source, keeping the
+// order of diagnostics reported at the same place.
+func Sort(diagnostics []Diagnostic) {
+	sort.SliceStable(diagnostics, func(first, second int) bool {
+		return diagnostics[first].Span.Start.Offset < diagnostics[second].Span.Start.Offset
+	})
+}
@@ -0,0 +1,183 @@
+package diagnostics
+
+import (
+	"bytes"
+	"compiler/token"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ToString renders the diagnostic on a single line, the way the parser
+// used to report its errors.
+func (diagnostic Diagnostic) ToString() string {
+	if !diagnostic.Span.Start.IsValid() {
+		return diagnostic.Message
+	}
+
+	return diagnostic.Span.Start.ToString() + ": " + diagnostic.Message
+}
+
+func RenderText(diagnostics []Diagnostic) string {
+	var output bytes.Buffer
+
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Span.Start.IsValid() {
+			output.WriteString(diagnostic.Span.Start.ToString() + ": ")
+		}
+
+		fmt.Fprintf(&output, "%s[%s]: %s\n", diagnostic.Severity, diagnostic.Code, diagnostic.Message)
+
+		for _, note := range diagnostic.Notes {
+			fmt.Fprintf(&output, "    note: %s\n", note)
+		}
+
+		if diagnostic.Fix != nil {
+			fmt.Fprintf(&output, "    help: %s\n", diagnostic.Fix.Message)
+		}
+	}
+
+	return output.String()
+}
+
+// RenderSnippet renders diagnostics the way rustc does, quoting the
+// offending source line and underlining the span with carets.
+func RenderSnippet(diagnostics []Diagnostic, source string) string {
+	var output bytes.Buffer
+
+	lines := strings.Split(source, "\n")
+
+	for _, diagnostic := range diagnostics {
+		fmt.Fprintf(&output, "%s[%s]: %s\n", diagnostic.Severity, diagnostic.Code, diagnostic.Message)
+
+		start := diagnostic.Span.Start
+		gutter := strings.Repeat(" ", len(strconv.Itoa(start.Line)))
+
+		if start.IsValid() && start.Line <= len(lines) {
+			line := strings.TrimRight(lines[start.Line-1], "\r")
+
+			fmt.Fprintf(&output, "%s--> %s\n", gutter, start.ToString())
+			fmt.Fprintf(&output, "%s |\n", gutter)
+			fmt.Fprintf(&output, "%d | %s\n", start.Line, line)
+			fmt.Fprintf(&output, "%s | %s%s\n", gutter, caretIndent(line, start.Column), strings.Repeat("^", caretWidth(diagnostic.Span, line)))
+		}
+
+		for _, note := range diagnostic.Notes {
+			fmt.Fprintf(&output, "%s = note: %s\n", gutter, note)
+		}
+
+		if diagnostic.Fix != nil {
+			fmt.Fprintf(&output, "%s = help: %s\n", gutter, diagnostic.Fix.Message)
+		}
+
+		output.WriteString("\n")
+	}
+
+	return output.String()
+}
+
+type jsonPosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Offset int `json:"offset"`
+}
+
+type jsonFix struct {
+	Message     string       `json:"message"`
+	Start       jsonPosition `json:"start"`
+	End         jsonPosition `json:"end"`
+	Replacement string       `json:"replacement"`
+}
+
+type jsonDiagnostic struct {
+	Severity Severity      `json:"severity"`
+	Code     string        `json:"code"`
+	Message  string        `json:"message"`
+	File     string        `json:"file,omitempty"`
+	Start    *jsonPosition `json:"start,omitempty"`
+	End      *jsonPosition `json:"end,omitempty"`
+	Notes    []string      `json:"notes"`
+	Fix      *jsonFix      `json:"fix,omitempty"`
+}
+
+func RenderJSON(diagnostics []Diagnostic) (string, error) {
+	output := make([]jsonDiagnostic, 0, len(diagnostics))
+
+	for _, diagnostic := range diagnostics {
+		rendered := jsonDiagnostic{
+			Severity: diagnostic.Severity,
+			Code:     diagnostic.Code,
+			Message:  diagnostic.Message,
+			Notes:    diagnostic.Notes,
+		}
+
+		if rendered.Notes == nil {
+			rendered.Notes = []string{}
+		}
+
+		if diagnostic.Span.Start.IsValid() {
+			start := toJSONPosition(diagnostic.Span.Start)
+			end := toJSONPosition(diagnostic.Span.End)
+
+			rendered.File = diagnostic.Span.Start.File
+			rendered.Start = &start
+			rendered.End = &end
+		}
+
+		if diagnostic.Fix != nil {
+			rendered.Fix = &jsonFix{
+				Message:     diagnostic.Fix.Message,
+				Start:       toJSONPosition(diagnostic.Fix.Span.Start),
+				End:         toJSONPosition(diagnostic.Fix.Span.End),
+				Replacement: diagnostic.Fix.Replacement,
+			}
+		}
+
+		output = append(output, rendered)
+	}
+
+	content, err := json.MarshalIndent(output, "", "  ")
+
+	return string(content), err
+}
+
+func toJSONPosition(position token.Position) jsonPosition {
+	return jsonPosition{Line: position.Line, Column: position.Column, Offset: position.Offset}
+}
+
+// caretIndent keeps the tabs of the quoted line, so the carets stay aligned
+// with the source however the terminal renders tabs.
+func caretIndent(line string, column int) string {
+	var indent bytes.Buffer
+
+	for index, character := range []rune(line) {
+		if index >= column-1 {
+			break
+		}
+
+		if character == '\t' {
+			indent.WriteByte('\t')
+		} else {
+			indent.WriteByte(' ')
+		}
+	}
+
+	return indent.String()
+}
+
+// caretWidth counts characters rather than bytes, like the columns.
+func caretWidth(span token.Span, line string) int {
+	width := span.End.Column - span.Start.Column
+
+	if span.End.Line != span.Start.Line {
+		width = utf8.RuneCountInString(line) - span.Start.Column + 1
+	}
+
+	if width < 1 {
+		return 1
+	}
+
+	return width
+}
@@ -0,0 +1,46 @@
+package evaluator
+
+import "math"
+
+// checkedIntegerOperation reports false when the exact result of the
+// operation does not fit into int64 and would silently wrap.
+func checkedIntegerOperation(operator string, firstValue, secondValue int64) (int64, bool) {
+	switch operator {
+	case "+":
+		result := firstValue + secondValue
+		return result, (result > firstValue) == (secondValue > 0)
+
+	case "-":
+		result := firstValue - secondValue
+		return result, (result < firstValue) == (secondValue > 0)
+
+	case "*":
+		if firstValue == 0 || secondValue == 0 {
+			return 0, true
+		}
+
+		result := firstValue * secondValue
+		overflow := result/secondValue != firstValue ||
+			(firstValue == -1 && secondValue == math.MinInt64) ||
+			(secondValue == -1 && firstValue == math.MinInt64)
+
+		return result, !overflow
+
+	case "/":
+		return firstValue / secondValue, !(firstValue == math.MinInt64 && secondValue == -1)
+
+	case "%":
+		if secondValue == -1 {
+			return 0, true
+		}
+
+		return firstValue % secondValue, true
+
+	case "<<":
+		result := firstValue << secondValue
+		return result, secondValue < 64 && result>>secondValue == firstValue
+
+	default:
+		return 0, false
+	}
+}
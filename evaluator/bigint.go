@@ -0,0 +1,90 @@
+package evaluator
+
+import (
+	"compiler/object"
+	"fmt"
+	"math/big"
+)
+
+// MAX_BIGINT_SHIFT bounds left shifts, which would otherwise let a script
+// allocate an arbitrary amount of memory in one operation.
+const MAX_BIGINT_SHIFT = 1 << 20
+
+// evalBigIntInfixExpression also serves a BigInt mixed with an Integer, the
+// Integer being promoted. Division and modulo truncate toward zero, as they
+// do for Integer.
+func evalBigIntInfixExpression(operator string, firstValue, secondValue *big.Int) object.Object {
+	if secondValue.Sign() == 0 && operator == "/" {
+		return newError("division by zero")
+	}
+
+	if secondValue.Sign() == 0 && operator == "%" {
+		return newError("modulo by zero")
+	}
+
+	switch operator {
+	case "+":
+		return &object.BigInt{Value: new(big.Int).Add(firstValue, secondValue)}
+
+	case "-":
+		return &object.BigInt{Value: new(big.Int).Sub(firstValue, secondValue)}
+
+	case "*":
+		return &object.BigInt{Value: new(big.Int).Mul(firstValue, secondValue)}
+
+	case "/":
+		return &object.BigInt{Value: new(big.Int).Quo(firstValue, secondValue)}
+
+	case "%":
+		return &object.BigInt{Value: new(big.Int).Rem(firstValue, secondValue)}
+
+	case "&":
+		return &object.BigInt{Value: new(big.Int).And(firstValue, secondValue)}
+
+	case "|":
+		return &object.BigInt{Value: new(big.Int).Or(firstValue, secondValue)}
+
+	case "^":
+		return &object.BigInt{Value: new(big.Int).Xor(firstValue, secondValue)}
+
+	case "<<", ">>":
+		return evalBigIntShift(operator, firstValue, secondValue)
+
+	case ">":
+		return convertBoolToBooleanObject(firstValue.Cmp(secondValue) > 0)
+
+	case "<":
+		return convertBoolToBooleanObject(firstValue.Cmp(secondValue) < 0)
+
+	case ">=":
+		return convertBoolToBooleanObject(firstValue.Cmp(secondValue) >= 0)
+
+	case "<=":
+		return convertBoolToBooleanObject(firstValue.Cmp(secondValue) <= 0)
+
+	case "==":
+		return convertBoolToBooleanObject(firstValue.Cmp(secondValue) == 0)
+
+	case "!=":
+		return convertBoolToBooleanObject(firstValue.Cmp(secondValue) != 0)
+
+	default:
+		return newError(fmt.Sprintf("unknown infix operator %s", operator))
+	}
+}
+
+func evalBigIntShift(operator string, value, count *big.Int) object.Object {
+	if count.Sign() < 0 {
+		return newError(fmt.Sprintf("negative shift count %s", count.String()))
+	}
+
+	if !count.IsUint64() || count.Uint64() > MAX_BIGINT_SHIFT {
+		return newError(fmt.Sprintf("shift count %s too large", count.String()))
+	}
+
+	if operator == "<<" {
+		return &object.BigInt{Value: new(big.Int).Lsh(value, uint(count.Uint64()))}
+	}
+
+	return &object.BigInt{Value: new(big.Int).Rsh(value, uint(count.Uint64()))}
+}
@@ -3,23 +3,301 @@ package evaluator
 import (
 	"compiler/object"
 	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"unicode/utf8"
 )
 
 var builtins = map[string]*object.Builtin{
 	"len": {
 		Fn: builtLen,
 	},
+	"first": {
+		Fn: builtFirst,
+	},
+	"last": {
+		Fn: builtLast,
+	},
+	"rest": {
+		Fn: builtRest,
+	},
+	"push": {
+		Fn: builtPush,
+	},
+	"int": {
+		Fn: builtInt,
+	},
+	"float": {
+		Fn: builtFloat,
+	},
+	"floor": {
+		Fn: roundingBuiltin("floor", math.Floor),
+	},
+	"ceil": {
+		Fn: roundingBuiltin("ceil", math.Ceil),
+	},
+	"round": {
+		Fn: roundingBuiltin("round", math.Round),
+	},
+	"sqrt": {
+		Fn: builtSqrt,
+	},
+	"bigint": {
+		Fn: builtBigInt,
+	},
+	"error": {
+		Fn: builtError,
+	},
 }
 
+// builtLen counts the bytes of a string, or its characters when called as
+// len(text, "runes").
 var builtLen object.BuiltinFn = func(args ...object.Object) object.Object {
+	if countOfArguments := len(args); countOfArguments != 1 && countOfArguments != 2 {
+		return newError(fmt.Sprintf("len expects 1 or 2 arguments, got %d", countOfArguments))
+	}
+
+	switch argument := args[0].(type) {
+	case *object.String:
+		if len(args) == 1 {
+			return &object.Integer{Value: int64(len(argument.Value))}
+		}
+
+		switch unit := args[1].(type) {
+		case *object.String:
+			if unit.Value == "bytes" {
+				return &object.Integer{Value: int64(len(argument.Value))}
+			}
+
+			if unit.Value == "runes" {
+				return &object.Integer{Value: int64(utf8.RuneCountInString(argument.Value))}
+			}
+		}
+
+		return newError(fmt.Sprintf("len unit must be \"bytes\" or \"runes\" but get %s", args[1].Inspect()))
+
+	case *object.Array:
+		if len(args) == 2 {
+			return newError(fmt.Sprintf("len unit applies only to strings but get %s", argument.GetObjectType()))
+		}
+
+		return &object.Integer{Value: int64(len(argument.Elements))}
+
+	default:
+		return newError(fmt.Sprintf("len supports only string or array but get %s", args[0].GetObjectType()))
+	}
+}
+
+var builtFirst object.BuiltinFn = func(args ...object.Object) object.Object {
+	array, errorObj := expectArrayArgument("first", 1, args)
+
+	if errorObj != nil {
+		return errorObj
+	}
+
+	if len(array.Elements) == 0 {
+		return NULL
+	}
+
+	return array.Elements[0]
+}
+
+var builtLast object.BuiltinFn = func(args ...object.Object) object.Object {
+	array, errorObj := expectArrayArgument("last", 1, args)
+
+	if errorObj != nil {
+		return errorObj
+	}
+
+	if len(array.Elements) == 0 {
+		return NULL
+	}
+
+	return array.Elements[len(array.Elements)-1]
+}
+
+var builtRest object.BuiltinFn = func(args ...object.Object) object.Object {
+	array, errorObj := expectArrayArgument("rest", 1, args)
+
+	if errorObj != nil {
+		return errorObj
+	}
+
+	if len(array.Elements) == 0 {
+		return NULL
+	}
+
+	elements := make([]object.Object, len(array.Elements)-1)
+	copy(elements, array.Elements[1:])
+
+	return &object.Array{Elements: elements}
+}
+
+var builtPush object.BuiltinFn = func(args ...object.Object) object.Object {
+	array, errorObj := expectArrayArgument("push", 2, args)
+
+	if errorObj != nil {
+		return errorObj
+	}
+
+	elements := make([]object.Object, len(array.Elements), len(array.Elements)+1)
+	copy(elements, array.Elements)
+
+	return &object.Array{Elements: append(elements, args[1])}
+}
+
+var builtInt object.BuiltinFn = func(args ...object.Object) object.Object {
 	if countOfArguments := len(args); countOfArguments != 1 {
-		return newError(fmt.Sprintf("wrong number of arguments: want 1, but get %d", countOfArguments))
+		return newArityError("int", 1, countOfArguments)
 	}
 
-	if stringObj, ok := args[0].(*object.String); ok {
-		return &object.Integer{Value: int64(len(stringObj.Value))}
+	switch argument := args[0].(type) {
+	case *object.Integer:
+		return argument
+
+	case *object.Float:
+		// float64(math.MaxInt64) rounds up to 2^63, which is already out of range
+		if math.IsNaN(argument.Value) || argument.Value < math.MinInt64 || argument.Value >= math.MaxInt64 {
+			return newError(fmt.Sprintf("can not convert %s to integer", argument.Inspect()))
+		}
+
+		return &object.Integer{Value: int64(argument.Value)}
+
+	case *object.BigInt:
+		if !argument.Value.IsInt64() {
+			return newError(fmt.Sprintf("can not convert %s to integer", argument.Inspect()))
+		}
+
+		return &object.Integer{Value: argument.Value.Int64()}
 
-	} else {
-		return newError(fmt.Sprintf("len supports only string but get %s", args[0].GetObjectType()))
+	case *object.String:
+		value, err := strconv.ParseInt(argument.Value, 10, 64)
+
+		if err != nil {
+			return newError(fmt.Sprintf("can not convert %q to integer", argument.Value))
+		}
+
+		return &object.Integer{Value: value}
+
+	default:
+		return newError(fmt.Sprintf("int supports only number or string but get %s", args[0].GetObjectType()))
+	}
+}
+
+var builtFloat object.BuiltinFn = func(args ...object.Object) object.Object {
+	if countOfArguments := len(args); countOfArguments != 1 {
+		return newArityError("float", 1, countOfArguments)
+	}
+
+	switch argument := args[0].(type) {
+	case *object.Integer, *object.BigInt:
+		return &object.Float{Value: toFloat(argument)}
+
+	case *object.Float:
+		return argument
+
+	case *object.String:
+		value, err := strconv.ParseFloat(argument.Value, 64)
+
+		if err != nil {
+			return newError(fmt.Sprintf("can not convert %q to float", argument.Value))
+		}
+
+		return &object.Float{Value: value}
+
+	default:
+		return newError(fmt.Sprintf("float supports only number or string but get %s", args[0].GetObjectType()))
+	}
+}
+
+var builtBigInt object.BuiltinFn = func(args ...object.Object) object.Object {
+	if countOfArguments := len(args); countOfArguments != 1 {
+		return newArityError("bigint", 1, countOfArguments)
+	}
+
+	switch argument := args[0].(type) {
+	case *object.Integer:
+		return &object.BigInt{Value: big.NewInt(argument.Value)}
+
+	case *object.BigInt:
+		return argument
+
+	case *object.Float:
+		if math.IsNaN(argument.Value) || math.IsInf(argument.Value, 0) {
+			return newError(fmt.Sprintf("can not convert %s to big integer", argument.Inspect()))
+		}
+
+		value, _ := big.NewFloat(argument.Value).Int(nil)
+
+		return &object.BigInt{Value: value}
+
+	case *object.String:
+		value, ok := new(big.Int).SetString(argument.Value, 10)
+
+		if !ok {
+			return newError(fmt.Sprintf("can not convert %q to big integer", argument.Value))
+		}
+
+		return &object.BigInt{Value: value}
+
+	default:
+		return newError(fmt.Sprintf("bigint supports only number or string but get %s", args[0].GetObjectType()))
+	}
+}
+
+// roundingBuiltin returns integers and big integers unchanged, they are
+// already whole.
+func roundingBuiltin(name string, rounding func(float64) float64) object.BuiltinFn {
+	return func(args ...object.Object) object.Object {
+		if errorObj := expectNumberArgument(name, args); errorObj != nil {
+			return errorObj
+		}
+
+		if isInteger(args[0]) {
+			return args[0]
+		}
+
+		return &object.Float{Value: rounding(args[0].(*object.Float).Value)}
+	}
+}
+
+var builtSqrt object.BuiltinFn = func(args ...object.Object) object.Object {
+	if errorObj := expectNumberArgument("sqrt", args); errorObj != nil {
+		return errorObj
 	}
+
+	value := toFloat(args[0])
+
+	if value < 0 {
+		return newError(fmt.Sprintf("sqrt of negative number %s", args[0].Inspect()))
+	}
+
+	return &object.Float{Value: math.Sqrt(value)}
+}
+
+func expectNumberArgument(name string, args []object.Object) object.Object {
+	if countOfArguments := len(args); countOfArguments != 1 {
+		return newArityError(name, 1, countOfArguments)
+	}
+
+	if !isNumber(args[0]) {
+		return newError(fmt.Sprintf("%s supports only number but get %s", name, args[0].GetObjectType()))
+	}
+
+	return nil
+}
+
+func expectArrayArgument(name string, countOfParameters int, args []object.Object) (*object.Array, object.Object) {
+	if countOfArguments := len(args); countOfArguments != countOfParameters {
+		return nil, newArityError(name, countOfParameters, countOfArguments)
+	}
+
+	array, ok := args[0].(*object.Array)
+
+	if !ok {
+		return nil, newError(fmt.Sprintf("%s supports only array but get %s", name, args[0].GetObjectType()))
+	}
+
+	return array, nil
 }
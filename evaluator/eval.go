@@ -3,16 +3,56 @@ package evaluator
 import (
 	"compiler/ast"
 	"compiler/object"
+	"context"
 	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"time"
 )
 
 var (
-	NULL  = &object.Null{}
-	TRUE  = &object.Boolean{Value: true}
-	FALSE = &object.Boolean{Value: false}
+	NULL     = &object.Null{}
+	TRUE     = &object.Boolean{Value: true}
+	FALSE    = &object.Boolean{Value: false}
+	BREAK    = &object.Break{}
+	CONTINUE = &object.Continue{}
 )
 
+// Limits bound an evaluation started with EvalContext; a zero MaxSteps or
+// Deadline leaves that limit off.
+type Limits struct {
+	MaxSteps int64
+	Deadline time.Time
+}
+
+// EvalContext evaluates node like Eval, but aborts it with an error of kind
+// object.CANCELED_KIND, object.DEADLINE_EXCEEDED_KIND or
+// object.STEP_LIMIT_KIND when ctx is done, the deadline passes or more than
+// MaxSteps nodes have been evaluated, whichever happens first.
+func EvalContext(ctx context.Context, node ast.Node, environment *object.Environment, limits Limits) object.Object {
+	if !limits.Deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, limits.Deadline)
+		defer cancel()
+	}
+
+	budget := environment.Budget()
+	budget.Start(ctx, limits.MaxSteps)
+	defer budget.Stop()
+
+	return Eval(node, environment)
+}
+
 func Eval(node ast.Node, environment *object.Environment) object.Object {
+	if abort := environment.Budget().Spend(); abort != nil {
+		if node == nil {
+			return abort
+		}
+
+		return locateError(node, abort)
+	}
+
 	switch node := node.(type) {
 
 	case *ast.Program:
@@ -27,6 +67,12 @@ func Eval(node ast.Node, environment *object.Environment) object.Object {
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
 
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
+
+	case *ast.BigIntLiteral:
+		return &object.BigInt{Value: node.Value}
+
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
 
@@ -35,6 +81,7 @@ func Eval(node ast.Node, environment *object.Environment) object.Object {
 
 	case *ast.FunctionLiteral:
 		return &object.Function{
+			Name:        node.Name,
 			Parameters:  node.Parameters,
 			Body:        node.Body,
 			Environment: environment,
@@ -50,21 +97,34 @@ func Eval(node ast.Node, environment *object.Environment) object.Object {
 		switch fn := fn.(type) {
 		case *object.Function:
 			{
-				extendedEnvironment, environmentError := createFunctionEnvironment(node.Arguments, fn.Parameters, environment)
-				if environmentError != nil {
-					return environmentError
+				arguments := evalArguments(node.Arguments, environment)
+
+				if len(arguments) == 1 && isError(arguments[0]) {
+					return arguments[0]
 				}
 
-				return unwrapReturnValue(evalBlockStatements(fn.Body, extendedEnvironment))
+				// the caller returns this call's value as is, so it hands the
+				// call back to applyFunction instead of growing the Go stack
+				if node.IsTail {
+					return &object.TailCall{Function: fn, Arguments: arguments, Call: node}
+				}
+
+				return applyFunction(node, fn, arguments, environment)
 			}
 
 		case *object.Builtin:
 			{
-				return fn.Fn(evalArguments(node.Arguments, environment)...)
+				arguments := evalArguments(node.Arguments, environment)
+
+				if len(arguments) == 1 && isError(arguments[0]) {
+					return arguments[0]
+				}
+
+				return locateError(node, fn.Fn(arguments...))
 			}
 
 		default:
-			return newError(fmt.Sprintf("not a function: %s", fn.GetObjectType()))
+			return locateError(node, newError(fmt.Sprintf("not a function: %s", fn.GetObjectType())))
 		}
 
 	case *ast.LetStatement:
@@ -74,7 +134,15 @@ func Eval(node ast.Node, environment *object.Environment) object.Object {
 			return value
 		}
 
-		environment.Set(node.Name.Value, value)
+		if environment.DeclaresConstant(node.Name.Value) {
+			return locateError(node.Name, newError(fmt.Sprintf("can not redeclare constant %s", node.Name.Value)))
+		}
+
+		if node.IsConstant() {
+			environment.SetConstant(node.Name.Value, value)
+		} else {
+			environment.Set(node.Name.Value, value)
+		}
 
 	case *ast.Identifier:
 		value, exist := environment.Get(node.Value)
@@ -87,7 +155,7 @@ func Eval(node ast.Node, environment *object.Environment) object.Object {
 			return builtIn
 		}
 
-		return newError(fmt.Sprintf("variable doesn`t exist %s", node.Value))
+		return locateError(node, newError(fmt.Sprintf("variable doesn`t exist %s", node.Value)))
 
 	case *ast.PrefixExpression:
 		right := Eval(node.Right, environment)
@@ -96,25 +164,80 @@ func Eval(node ast.Node, environment *object.Environment) object.Object {
 			return right
 		}
 
-		return evalPrefixExpression(node.Operator, right)
+		return locateError(node, evalPrefixExpression(node.Operator, right, environment.Settings()))
 
 	case *ast.InfixExpression:
+		if node.Operator == "&&" || node.Operator == "||" {
+			return evalLogicalExpression(node, environment)
+		}
+
+		left := Eval(node.Left, environment)
+
+		if isError(left) {
+			return left
+		}
+
 		right := Eval(node.Right, environment)
 
 		if isError(right) {
 			return right
 		}
 
+		return locateError(node, evalInfixExpression(node.Operator, left, right, environment.Settings()))
+
+	case *ast.IfExpression:
+		return evalIfExpression(node, environment)
+
+	case *ast.TryExpression:
+		return evalTryExpression(node, environment)
+
+	case *ast.ThrowStatement:
+		return evalThrowStatement(node, environment)
+
+	case *ast.WhileStatement:
+		return evalWhileStatement(node, environment)
+
+	case *ast.ForStatement:
+		return evalForStatement(node, environment)
+
+	case *ast.ForInStatement:
+		return evalForInStatement(node, environment)
+
+	case *ast.BreakStatement:
+		return BREAK
+
+	case *ast.ContinueStatement:
+		return CONTINUE
+
+	case *ast.ArrayLiteral:
+		elements := evalArguments(node.Elements, environment)
+
+		if len(elements) == 1 && isError(elements[0]) {
+			return elements[0]
+		}
+
+		return &object.Array{Elements: elements}
+
+	case *ast.HashLiteral:
+		return evalHashLiteral(node, environment)
+
+	case *ast.IndexExpression:
 		left := Eval(node.Left, environment)
 
 		if isError(left) {
 			return left
 		}
 
-		return evalInfixExpression(node.Operator, left, right)
+		index := Eval(node.Index, environment)
 
-	case *ast.IfExpression:
-		return evalIfExpression(node, environment)
+		if isError(index) {
+			return index
+		}
+
+		return locateError(node, evalIndexExpression(left, index))
+
+	case *ast.AssignExpression:
+		return evalAssignExpression(node, environment)
 
 	case *ast.ReturnStatement:
 		value := Eval(node.Value, environment)
@@ -129,6 +252,114 @@ func Eval(node ast.Node, environment *object.Environment) object.Object {
 	return NULL
 }
 
+func evalAssignExpression(node *ast.AssignExpression, environment *object.Environment) object.Object {
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		current, exist := environment.Get(target.Value)
+
+		if !exist {
+			return locateError(target, newError(fmt.Sprintf("assignment to undeclared variable %s", target.Value)))
+		}
+
+		if environment.IsConstant(target.Value) {
+			return locateError(target, newError(fmt.Sprintf("can not reassign constant %s", target.Value)))
+		}
+
+		value := Eval(node.Value, environment)
+
+		if isError(value) {
+			return value
+		}
+
+		value = locateError(node, combineAssignedValue(node.Operator, current, value, environment.Settings()))
+
+		if isError(value) {
+			return value
+		}
+
+		environment.Assign(target.Value, value)
+
+		return value
+
+	case *ast.IndexExpression:
+		left := Eval(target.Left, environment)
+
+		if isError(left) {
+			return left
+		}
+
+		index := Eval(target.Index, environment)
+
+		if isError(index) {
+			return index
+		}
+
+		value := Eval(node.Value, environment)
+
+		if isError(value) {
+			return value
+		}
+
+		return locateError(node, evalIndexAssignment(node.Operator, left, index, value, environment.Settings()))
+
+	default:
+		return locateError(node, newError(fmt.Sprintf("can not assign to %s", node.Target.ToString())))
+	}
+}
+
+// combineAssignedValue applies the arithmetic of a compound assignment
+// operator such as "+=" to the current value of the target.
+func combineAssignedValue(operator string, current, value object.Object, settings *object.Settings) object.Object {
+	if operator == "=" {
+		return value
+	}
+
+	return evalInfixExpression(strings.TrimSuffix(operator, "="), current, value, settings)
+}
+
+func evalIndexAssignment(operator string, left, index, value object.Object, settings *object.Settings) object.Object {
+	switch left := left.(type) {
+	case *object.Array:
+		position, ok := index.(*object.Integer)
+
+		if !ok {
+			return newError(fmt.Sprintf("array index must be INTEGER but get %s", index.GetObjectType()))
+		}
+
+		if position.Value < 0 || position.Value >= int64(len(left.Elements)) {
+			return newError(fmt.Sprintf("index %d out of range for array of length %d", position.Value, len(left.Elements)))
+		}
+
+		value = combineAssignedValue(operator, left.Elements[position.Value], value, settings)
+
+		if !isError(value) {
+			left.Elements[position.Value] = value
+		}
+
+		return value
+
+	case *object.Hash:
+		current := evalHashIndexExpression(left, index)
+
+		if isError(current) {
+			return current
+		}
+
+		value = combineAssignedValue(operator, current, value, settings)
+
+		if isError(value) {
+			return value
+		}
+
+		insertHashPair(left, index, value)
+
+		return value
+
+	default:
+		return newError(fmt.Sprintf("index assignment not supported: %s[%s]", left.GetObjectType(), index.GetObjectType()))
+	}
+}
+
 func evalProgram(program *ast.Program, environment *object.Environment) object.Object {
 	var result object.Object
 
@@ -141,6 +372,9 @@ func evalProgram(program *ast.Program, environment *object.Environment) object.O
 
 		case *object.Error:
 			return result
+
+		case *object.Break, *object.Continue:
+			return rejectLoopControl(statement, result)
 		}
 
 	}
@@ -149,12 +383,13 @@ func evalProgram(program *ast.Program, environment *object.Environment) object.O
 }
 
 func evalBlockStatements(blockStatement *ast.BlockStatement, environment *object.Environment) object.Object {
-	var result object.Object
+	var result object.Object = NULL
 
 	for _, statement := range blockStatement.Statements {
 		result = Eval(statement, environment)
 
-		if result.GetObjectType() == object.RETURN_VALUE_OBJ || result.GetObjectType() == object.ERROR_OBJ {
+		switch result.GetObjectType() {
+		case object.RETURN_VALUE_OBJ, object.ERROR_OBJ, object.BREAK_OBJ, object.CONTINUE_OBJ:
 			return result
 		}
 	}
@@ -186,13 +421,13 @@ func convertBoolToBooleanObject(argument bool) *object.Boolean {
 	return FALSE
 }
 
-func evalPrefixExpression(operator string, argument object.Object) object.Object {
+func evalPrefixExpression(operator string, argument object.Object, settings *object.Settings) object.Object {
 	switch operator {
 	case "!":
 		return evalBangOperatorExpression(argument)
 
 	case "-":
-		return evalMinusPrefixOperatorExpression(argument)
+		return evalMinusPrefixOperatorExpression(argument, settings)
 
 	default:
 		return newError(fmt.Sprintf("unknown prefix operator %s", operator))
@@ -208,6 +443,14 @@ func evalBangOperatorExpression(argument object.Object) object.Object {
 		booleanValue := argument.(*object.Integer).Value != 0
 		return convertBoolToBooleanObject(!booleanValue)
 
+	case object.FLOAT_OBJ:
+		booleanValue := argument.(*object.Float).Value != 0
+		return convertBoolToBooleanObject(!booleanValue)
+
+	case object.BIGINT_OBJ:
+		booleanValue := argument.(*object.BigInt).Value.Sign() != 0
+		return convertBoolToBooleanObject(!booleanValue)
+
 	case object.NULL_OBJ:
 		return FALSE
 
@@ -216,24 +459,42 @@ func evalBangOperatorExpression(argument object.Object) object.Object {
 	}
 }
 
-func evalMinusPrefixOperatorExpression(argument object.Object) object.Object {
+func evalMinusPrefixOperatorExpression(argument object.Object, settings *object.Settings) object.Object {
+	if argument.GetObjectType() == object.FLOAT_OBJ {
+		return &object.Float{Value: -argument.(*object.Float).Value}
+	}
+
+	if argument.GetObjectType() == object.BIGINT_OBJ {
+		return &object.BigInt{Value: new(big.Int).Neg(argument.(*object.BigInt).Value)}
+	}
+
 	if argument.GetObjectType() != object.INTEGER_OBJ {
 		return newError(fmt.Sprintf("%s should be a number", argument.Inspect()))
 	}
 
 	value := argument.(*object.Integer).Value
 
+	if settings.CheckedArithmetic && value == math.MinInt64 {
+		return newError(fmt.Sprintf("integer overflow: -(%d)", value))
+	}
+
 	return &object.Integer{Value: -value}
 }
 
-func evalInfixExpression(operator string, firstArgument, secondArgument object.Object) object.Object {
+func evalInfixExpression(operator string, firstArgument, secondArgument object.Object, settings *object.Settings) object.Object {
 	firstArgumentType := firstArgument.GetObjectType()
 	secondArgumentType := secondArgument.GetObjectType()
 
 	switch {
 
 	case firstArgumentType == object.INTEGER_OBJ && secondArgumentType == object.INTEGER_OBJ:
-		return evalIntegerInfixExpression(operator, firstArgument, secondArgument)
+		return evalIntegerInfixExpression(operator, firstArgument, secondArgument, settings)
+
+	case isInteger(firstArgument) && isInteger(secondArgument):
+		return evalBigIntInfixExpression(operator, toBigInt(firstArgument), toBigInt(secondArgument))
+
+	case isNumber(firstArgument) && isNumber(secondArgument):
+		return evalFloatInfixExpression(operator, toFloat(firstArgument), toFloat(secondArgument))
 
 	case firstArgumentType == object.STRING_OBJ && secondArgumentType == object.STRING_OBJ:
 		return evalStringInfixExpression(operator, firstArgument, secondArgument)
@@ -249,14 +510,64 @@ func evalInfixExpression(operator string, firstArgument, secondArgument object.O
 	}
 }
 
-func evalIntegerInfixExpression(operator string, firstArgument, secondArgument object.Object) object.Object {
+// evalLogicalExpression evaluates the right operand of && and || only when
+// the left one does not already decide the result.
+func evalLogicalExpression(node *ast.InfixExpression, environment *object.Environment) object.Object {
+	left := Eval(node.Left, environment)
+
+	if isError(left) {
+		return left
+	}
+
+	if isTruthy(left) == (node.Operator == "||") {
+		return convertBoolToBooleanObject(isTruthy(left))
+	}
+
+	right := Eval(node.Right, environment)
+
+	if isError(right) {
+		return right
+	}
+
+	return convertBoolToBooleanObject(isTruthy(right))
+}
+
+func evalIntegerInfixExpression(operator string, firstArgument, secondArgument object.Object, settings *object.Settings) object.Object {
 	firstValue := firstArgument.(*object.Integer).Value
 	secondValue := secondArgument.(*object.Integer).Value
 
+	if secondValue == 0 && operator == "/" {
+		return newError("division by zero")
+	}
+
+	if secondValue == 0 && operator == "%" {
+		return newError("modulo by zero")
+	}
+
+	if secondValue < 0 && (operator == "<<" || operator == ">>") {
+		return newError(fmt.Sprintf("negative shift count %d", secondValue))
+	}
+
+	if settings.CheckedArithmetic {
+		switch operator {
+		case "+", "-", "*", "/", "%", "<<":
+			result, ok := checkedIntegerOperation(operator, firstValue, secondValue)
+
+			if !ok {
+				return newError(fmt.Sprintf("integer overflow: %d %s %d", firstValue, operator, secondValue))
+			}
+
+			return &object.Integer{Value: result}
+		}
+	}
+
 	switch operator {
 	case "/":
 		return &object.Integer{Value: firstValue / secondValue}
 
+	case "%":
+		return &object.Integer{Value: firstValue % secondValue}
+
 	case "*":
 		return &object.Integer{Value: firstValue * secondValue}
 
@@ -272,6 +583,27 @@ func evalIntegerInfixExpression(operator string, firstArgument, secondArgument o
 	case "<":
 		return convertBoolToBooleanObject(firstValue < secondValue)
 
+	case ">=":
+		return convertBoolToBooleanObject(firstValue >= secondValue)
+
+	case "<=":
+		return convertBoolToBooleanObject(firstValue <= secondValue)
+
+	case "&":
+		return &object.Integer{Value: firstValue & secondValue}
+
+	case "|":
+		return &object.Integer{Value: firstValue | secondValue}
+
+	case "^":
+		return &object.Integer{Value: firstValue ^ secondValue}
+
+	case "<<":
+		return &object.Integer{Value: firstValue << secondValue}
+
+	case ">>":
+		return &object.Integer{Value: firstValue >> secondValue}
+
 	case "==":
 		return convertBoolToBooleanObject(firstValue == secondValue)
 
@@ -285,6 +617,84 @@ func evalIntegerInfixExpression(operator string, firstArgument, secondArgument o
 
 }
 
+// evalFloatInfixExpression also serves mixed operands, the integer one being
+// promoted to float.
+func evalFloatInfixExpression(operator string, firstValue, secondValue float64) object.Object {
+	switch operator {
+	case "/":
+		return &object.Float{Value: firstValue / secondValue}
+
+	case "%":
+		return &object.Float{Value: math.Mod(firstValue, secondValue)}
+
+	case "*":
+		return &object.Float{Value: firstValue * secondValue}
+
+	case "+":
+		return &object.Float{Value: firstValue + secondValue}
+
+	case "-":
+		return &object.Float{Value: firstValue - secondValue}
+
+	case ">":
+		return convertBoolToBooleanObject(firstValue > secondValue)
+
+	case "<":
+		return convertBoolToBooleanObject(firstValue < secondValue)
+
+	case ">=":
+		return convertBoolToBooleanObject(firstValue >= secondValue)
+
+	case "<=":
+		return convertBoolToBooleanObject(firstValue <= secondValue)
+
+	case "==":
+		return convertBoolToBooleanObject(firstValue == secondValue)
+
+	case "!=":
+		return convertBoolToBooleanObject(firstValue != secondValue)
+
+	default:
+		return newError(fmt.Sprintf("unknown infix operator %s for floats", operator))
+	}
+}
+
+func isNumber(argument object.Object) bool {
+	return isInteger(argument) || argument.GetObjectType() == object.FLOAT_OBJ
+}
+
+func isInteger(argument object.Object) bool {
+	switch argument.GetObjectType() {
+	case object.INTEGER_OBJ, object.BIGINT_OBJ:
+		return true
+
+	default:
+		return false
+	}
+}
+
+func toFloat(argument object.Object) float64 {
+	switch argument := argument.(type) {
+	case *object.Integer:
+		return float64(argument.Value)
+
+	case *object.BigInt:
+		value, _ := new(big.Float).SetInt(argument.Value).Float64()
+		return value
+
+	default:
+		return argument.(*object.Float).Value
+	}
+}
+
+func toBigInt(argument object.Object) *big.Int {
+	if integer, ok := argument.(*object.Integer); ok {
+		return big.NewInt(integer.Value)
+	}
+
+	return argument.(*object.BigInt).Value
+}
+
 func evalStringInfixExpression(operator string, firstArgument, secondArgument object.Object) object.Object {
 	firstValue := firstArgument.(*object.String).Value
 	secondValue := secondArgument.(*object.String).Value
@@ -296,6 +706,85 @@ func evalStringInfixExpression(operator string, firstArgument, secondArgument ob
 	return newError(fmt.Sprintf("unknown infix operator %s", operator))
 }
 
+func evalIndexExpression(left, index object.Object) object.Object {
+	switch {
+	case left.GetObjectType() == object.ARRAY_OBJ && index.GetObjectType() == object.INTEGER_OBJ:
+		return evalArrayIndexExpression(left, index)
+
+	case left.GetObjectType() == object.HASH_OBJ:
+		return evalHashIndexExpression(left, index)
+
+	case left.GetObjectType() == object.ERROR_VALUE_OBJ:
+		return evalErrorValueIndexExpression(left.(*object.ErrorValue), index)
+
+	default:
+		return newError(fmt.Sprintf("index operator not supported: %s[%s]", left.GetObjectType(), index.GetObjectType()))
+	}
+}
+
+func evalArrayIndexExpression(array, index object.Object) object.Object {
+	elements := array.(*object.Array).Elements
+	position := index.(*object.Integer).Value
+
+	if position < 0 || position >= int64(len(elements)) {
+		return NULL
+	}
+
+	return elements[position]
+}
+
+func evalHashIndexExpression(hash, index object.Object) object.Object {
+	key, ok := index.(object.Hashable)
+
+	if !ok {
+		return newError(fmt.Sprintf("unusable as hash key: %s", index.GetObjectType()))
+	}
+
+	value, exist := hash.(*object.Hash).Get(key)
+
+	if !exist {
+		return NULL
+	}
+
+	return value
+}
+
+func evalHashLiteral(literal *ast.HashLiteral, environment *object.Environment) object.Object {
+	hash := object.NewHash()
+
+	for _, pair := range literal.Pairs {
+		key := Eval(pair.Key, environment)
+
+		if isError(key) {
+			return key
+		}
+
+		value := Eval(pair.Value, environment)
+
+		if isError(value) {
+			return value
+		}
+
+		if errorObj := insertHashPair(hash, key, value); errorObj != nil {
+			return locateError(pair.Key, errorObj)
+		}
+	}
+
+	return hash
+}
+
+func insertHashPair(hash *object.Hash, key, value object.Object) object.Object {
+	hashableKey, ok := key.(object.Hashable)
+
+	if !ok {
+		return newError(fmt.Sprintf("unusable as hash key: %s", key.GetObjectType()))
+	}
+
+	hash.Set(hashableKey, value)
+
+	return nil
+}
+
 func evalIfExpression(argument *ast.IfExpression, environment *object.Environment) object.Object {
 	condition := Eval(argument.Condition, environment)
 
@@ -314,23 +803,230 @@ func evalIfExpression(argument *ast.IfExpression, environment *object.Environmen
 	return NULL
 }
 
-func createFunctionEnvironment(arguments []ast.Expression, parameters []*ast.Identifier, environment *object.Environment) (*object.Environment, object.Object) {
-	extendedEnvironment := environment.Extend()
-	evaledArguments := evalArguments(arguments, extendedEnvironment)
+func evalWhileStatement(statement *ast.WhileStatement, environment *object.Environment) object.Object {
+	for {
+		condition := Eval(statement.Condition, environment)
+
+		if isError(condition) {
+			return condition
+		}
+
+		if !isTruthy(condition) {
+			return NULL
+		}
+
+		if result, stop := evalLoopBody(statement.Body, environment); stop {
+			return result
+		}
+	}
+}
+
+func evalForStatement(statement *ast.ForStatement, environment *object.Environment) object.Object {
+	loopEnvironment := environment.Extend()
+
+	if statement.Init != nil {
+		if init := Eval(statement.Init, loopEnvironment); isError(init) {
+			return init
+		}
+	}
+
+	for {
+		if statement.Condition != nil {
+			condition := Eval(statement.Condition, loopEnvironment)
+
+			if isError(condition) {
+				return condition
+			}
+
+			if !isTruthy(condition) {
+				return NULL
+			}
+		}
+
+		if result, stop := evalLoopBody(statement.Body, loopEnvironment); stop {
+			return result
+		}
+
+		if statement.Update != nil {
+			if update := Eval(statement.Update, loopEnvironment); isError(update) {
+				return update
+			}
+		}
+	}
+}
+
+func evalForInStatement(statement *ast.ForInStatement, environment *object.Environment) object.Object {
+	iterable := Eval(statement.Iterable, environment)
+
+	if isError(iterable) {
+		return iterable
+	}
+
+	array, errorObj := toIterable(iterable)
+
+	if errorObj != nil {
+		return locateError(statement.Iterable, errorObj)
+	}
+
+	for _, element := range array.Elements {
+		iterationEnvironment := environment.Extend()
+		iterationEnvironment.Set(statement.Variable.Value, element)
+
+		if result, stop := evalLoopBody(statement.Body, iterationEnvironment); stop {
+			return result
+		}
+	}
+
+	return NULL
+}
+
+func toIterable(iterable object.Object) (*object.Array, object.Object) {
+	array, ok := iterable.(*object.Array)
+
+	if !ok {
+		return nil, newError(fmt.Sprintf("for-in supports only array but get %s", iterable.GetObjectType()))
+	}
+
+	return array, nil
+}
+
+// evalLoopBody runs one iteration and reports whether the loop has to stop,
+// along with the value the loop statement then evaluates to.
+func evalLoopBody(body *ast.BlockStatement, environment *object.Environment) (object.Object, bool) {
+	switch result := Eval(body, environment).(type) {
+	case *object.Break:
+		return NULL, true
+
+	case *object.ReturnValue, *object.Error:
+		return result, true
 
-	if len(evaledArguments) == 1 && isError(evaledArguments[0]) {
-		return extendedEnvironment, evaledArguments[0]
+	default:
+		return nil, false
 	}
+}
+
+// rejectLoopControl turns a break or continue that escaped every loop into
+// an error; the parser rejects them, but hand-built trees may still hold one.
+func rejectLoopControl(node ast.Node, result object.Object) object.Object {
+	switch result.(type) {
+	case *object.Break:
+		return locateError(node, newError("break outside of a loop"))
+
+	case *object.Continue:
+		return locateError(node, newError("continue outside of a loop"))
+
+	default:
+		return result
+	}
+}
+
+// applyFunction runs fn and then, in the same Go frame and call stack
+// frame, every call the function body ends with in tail position.
+func applyFunction(node *ast.CallExpression, fn *object.Function, arguments []object.Object, environment *object.Environment) object.Object {
+	callStack := environment.CallStack()
 
-	for index, argument := range evaledArguments {
-		extendedEnvironment.Set(parameters[index].Value, argument)
+	// deep recursion would otherwise exhaust the Go stack, a fatal error
+	// no script or host can recover from
+	if maxCallDepth := environment.Settings().CallDepthLimit(); callStack.Depth() >= maxCallDepth {
+		return traceError(locateError(node, newRecursionError(maxCallDepth)), callStack)
+	}
+
+	extendedEnvironment, arityError := createFunctionEnvironment(fn, arguments)
+
+	if arityError != nil {
+		return traceError(locateError(node, arityError), callStack)
+	}
+
+	frame := object.CallFrame{Function: functionName(fn), Span: node.GetSpan()}
+	callStack.Push(frame)
+	defer callStack.Pop()
+
+	for {
+		result := unwrapReturnValue(rejectLoopControl(node, evalBlockStatements(fn.Body, extendedEnvironment)))
+		tailCall, ok := result.(*object.TailCall)
+
+		if !ok {
+			return traceError(result, callStack)
+		}
+
+		node, fn = tailCall.Call, tailCall.Function
+		extendedEnvironment, arityError = createFunctionEnvironment(fn, tailCall.Arguments)
+
+		if arityError != nil {
+			return traceError(locateError(node, arityError), callStack)
+		}
+
+		// the frame still returns to the original call site
+		frame.Function = functionName(fn)
+		callStack.Pop()
+		callStack.Push(frame)
+	}
+}
+
+func createFunctionEnvironment(fn *object.Function, arguments []object.Object) (*object.Environment, object.Object) {
+	if len(arguments) != len(fn.Parameters) {
+		return nil, newArityError(fn.Name, len(fn.Parameters), len(arguments))
+	}
+
+	// the body runs in the scope the function was defined in, so closures
+	// read and assign the bindings they captured rather than the caller's
+	extendedEnvironment := fn.Environment.Extend()
+
+	for index, argument := range arguments {
+		extendedEnvironment.Set(fn.Parameters[index].Value, argument)
 	}
 
 	return extendedEnvironment, nil
 }
 
 func newError(errorMessage string) object.Object {
-	return &object.Error{Message: errorMessage}
+	return &object.Error{Message: errorMessage, Kind: object.RUNTIME_ERROR_KIND}
+}
+
+func newArityError(name string, countOfParameters, countOfArguments int) object.Object {
+	if name == "" {
+		name = "anonymous function"
+	}
+
+	noun := "arguments"
+
+	if countOfParameters == 1 {
+		noun = "argument"
+	}
+
+	return newError(fmt.Sprintf("%s expects %d %s, got %d", name, countOfParameters, noun, countOfArguments))
+}
+
+func newRecursionError(maxCallDepth int) object.Object {
+	return newError(fmt.Sprintf("maximum recursion depth %d exceeded", maxCallDepth))
+}
+
+// locateError stamps an error raised while evaluating node with the node
+// position, keeping the innermost position when the error is already located.
+func locateError(node ast.Node, result object.Object) object.Object {
+	if errorObj, ok := result.(*object.Error); ok && !errorObj.Span.Start.IsValid() {
+		errorObj.Span = node.GetSpan()
+	}
+
+	return result
+}
+
+// traceError records the calls in progress on an error that has not been
+// traced yet, which happens when it leaves the function it was raised in.
+func traceError(result object.Object, callStack *object.CallStack) object.Object {
+	if errorObj, ok := result.(*object.Error); ok && errorObj.Trace == nil {
+		errorObj.Trace = callStack.Trace()
+	}
+
+	return result
+}
+
+func functionName(fn *object.Function) string {
+	if fn.Name == "" {
+		return "fn"
+	}
+
+	return fn.Name
 }
 
 func isTruthy(argument object.Object) bool {
@@ -341,6 +1037,12 @@ func isTruthy(argument object.Object) bool {
 	case object.INTEGER_OBJ:
 		return argument.(*object.Integer).Value != 0
 
+	case object.FLOAT_OBJ:
+		return argument.(*object.Float).Value != 0
+
+	case object.BIGINT_OBJ:
+		return argument.(*object.BigInt).Value.Sign() != 0
+
 	default:
 		return false
 	}
@@ -0,0 +1,57 @@
+package evaluator
+
+import (
+	"compiler/lexer"
+	"compiler/object"
+	"compiler/parser"
+	"context"
+	"testing"
+	"time"
+)
+
+func evalWithLimits(ctx context.Context, input string, limits Limits) object.Object {
+	program := parser.New(lexer.New(input)).ParseProgram()
+
+	return EvalContext(ctx, program, object.NewEnvironment(), limits)
+}
+
+func TestEvalContextAborts(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		ctx    context.Context
+		input  string
+		limits Limits
+		kind   string
+	}{
+		{context.Background(), "while (true) { }", Limits{MaxSteps: 1000}, object.STEP_LIMIT_KIND},
+		{context.Background(), "while (true) { }", Limits{Deadline: time.Now().Add(10 * time.Millisecond)}, object.DEADLINE_EXCEEDED_KIND},
+		{canceled, "let f = fn(n) { f(n + 1) }; f(0)", Limits{}, object.CANCELED_KIND},
+		{context.Background(), "while (true) { try { 1 } catch { 2 } finally { 3 } }", Limits{MaxSteps: 1000}, object.STEP_LIMIT_KIND},
+		{context.Background(), `let spin = fn() { while (true) { } }; try { len(spin()) } catch (e) { "caught: " + e["kind"] }`, Limits{Deadline: time.Now().Add(10 * time.Millisecond)}, object.DEADLINE_EXCEEDED_KIND},
+	}
+
+	for _, test := range tests {
+		errorObj, ok := evalWithLimits(test.ctx, test.input, test.limits).(*object.Error)
+
+		if !ok || !errorObj.IsAbort() || errorObj.Kind != test.kind {
+			t.Errorf("%s: expected an abort of kind %s, got %v", test.input, test.kind, errorObj)
+		}
+	}
+}
+
+func TestScriptsCanNotForgeAborts(t *testing.T) {
+	inputs := []string{
+		`throw error("x", "Canceled")`,
+		`let e = try { throw "x" } catch (e) { e }; throw e`,
+	}
+
+	for _, input := range inputs {
+		errorObj, ok := evalWithLimits(context.Background(), input, Limits{}).(*object.Error)
+
+		if !ok || errorObj.IsAbort() {
+			t.Errorf("%s: expected an ordinary error, got %v", input, errorObj)
+		}
+	}
+}
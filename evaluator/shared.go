@@ -0,0 +1,64 @@
+package evaluator
+
+import "compiler/object"
+
+// The functions below expose the evaluator semantics to the bytecode VM,
+// so both engines produce the same objects for the same program.
+
+func EvalPrefixOperator(operator string, argument object.Object, settings *object.Settings) object.Object {
+	return evalPrefixExpression(operator, argument, settings)
+}
+
+func EvalInfixOperator(operator string, firstArgument, secondArgument object.Object, settings *object.Settings) object.Object {
+	return evalInfixExpression(operator, firstArgument, secondArgument, settings)
+}
+
+func EvalIndexOperator(left, index object.Object) object.Object {
+	return evalIndexExpression(left, index)
+}
+
+// EvalIndexAssignment stores value at left[index], combining it with the
+// current element first for a compound operator such as "+=".
+func EvalIndexAssignment(operator string, left, index, value object.Object, settings *object.Settings) object.Object {
+	return evalIndexAssignment(operator, left, index, value, settings)
+}
+
+// InsertHashPair returns an error object when key can not be hashed.
+func InsertHashPair(hash *object.Hash, key, value object.Object) object.Object {
+	return insertHashPair(hash, key, value)
+}
+
+// NewError builds a runtime error the way the evaluator reports them.
+func NewError(errorMessage string) object.Object {
+	return newError(errorMessage)
+}
+
+func RaiseValue(value object.Object) object.Object {
+	return raiseValue(value)
+}
+
+func CatchError(errorObj *object.Error) *object.ErrorValue {
+	return catchError(errorObj)
+}
+
+func NewArityError(name string, countOfParameters, countOfArguments int) object.Object {
+	return newArityError(name, countOfParameters, countOfArguments)
+}
+
+func NewRecursionError(maxCallDepth int) object.Object {
+	return newRecursionError(maxCallDepth)
+}
+
+func ToIterable(iterable object.Object) (*object.Array, object.Object) {
+	return toIterable(iterable)
+}
+
+func IsTruthy(argument object.Object) bool {
+	return isTruthy(argument)
+}
+
+func LookupBuiltin(name string) (*object.Builtin, bool) {
+	builtin, exist := builtins[name]
+
+	return builtin, exist
+}
@@ -0,0 +1,129 @@
+package evaluator
+
+import (
+	"compiler/ast"
+	"compiler/object"
+	"fmt"
+)
+
+var builtError object.BuiltinFn = func(args ...object.Object) object.Object {
+	if countOfArguments := len(args); countOfArguments != 1 && countOfArguments != 2 {
+		return newError(fmt.Sprintf("error expects 1 or 2 arguments, got %d", countOfArguments))
+	}
+
+	message, ok := args[0].(*object.String)
+
+	if !ok {
+		return newError(fmt.Sprintf("error message must be STRING but get %s", args[0].GetObjectType()))
+	}
+
+	errorValue := &object.ErrorValue{Message: message.Value, Kind: object.ERROR_KIND}
+
+	if len(args) == 2 {
+		kind, ok := args[1].(*object.String)
+
+		if !ok {
+			return newError(fmt.Sprintf("error kind must be STRING but get %s", args[1].GetObjectType()))
+		}
+
+		if object.IsReservedKind(kind.Value) {
+			return newError(fmt.Sprintf("error kind %s is reserved for aborted evaluations", kind.Value))
+		}
+
+		errorValue.Kind = kind.Value
+	}
+
+	return errorValue
+}
+
+func evalThrowStatement(node *ast.ThrowStatement, environment *object.Environment) object.Object {
+	value := Eval(node.Value, environment)
+
+	if isError(value) {
+		return value
+	}
+
+	return locateError(node, raiseValue(value))
+}
+
+// raiseValue turns a thrown value into the error that propagates: error
+// values keep their kind and position, anything else becomes the message.
+func raiseValue(value object.Object) object.Object {
+	switch value := value.(type) {
+	case *object.ErrorValue:
+		return &object.Error{Message: value.Message, Kind: value.Kind, Span: value.Span, Trace: value.Trace}
+
+	case *object.String:
+		return &object.Error{Message: value.Value, Kind: object.ERROR_KIND}
+
+	default:
+		return &object.Error{Message: value.Inspect(), Kind: object.ERROR_KIND}
+	}
+}
+
+// catchError is the value a catch clause binds for a caught error.
+func catchError(errorObj *object.Error) *object.ErrorValue {
+	kind := errorObj.Kind
+
+	if kind == "" {
+		kind = object.RUNTIME_ERROR_KIND
+	}
+
+	return &object.ErrorValue{Message: errorObj.Message, Kind: kind, Span: errorObj.Span, Trace: errorObj.Trace}
+}
+
+func evalTryExpression(node *ast.TryExpression, environment *object.Environment) object.Object {
+	result := Eval(node.Block, environment)
+
+	if errorObj, ok := traceError(result, environment.CallStack()).(*object.Error); ok && node.Catch != nil && !errorObj.IsAbort() {
+		catchEnvironment := environment.Extend()
+
+		if node.Parameter != nil {
+			catchEnvironment.Set(node.Parameter.Value, catchError(errorObj))
+		}
+
+		result = evalBlockStatements(node.Catch, catchEnvironment)
+	}
+
+	// an aborted evaluation runs no more code, finally blocks included
+	if errorObj, ok := result.(*object.Error); ok && errorObj.IsAbort() {
+		return result
+	}
+
+	if node.Finally != nil {
+		finally := Eval(node.Finally, environment)
+
+		// a finally block that leaves abruptly overrides the try outcome
+		switch finally.GetObjectType() {
+		case object.RETURN_VALUE_OBJ, object.ERROR_OBJ, object.BREAK_OBJ, object.CONTINUE_OBJ:
+			return finally
+		}
+	}
+
+	return result
+}
+
+// evalErrorValueIndexExpression reads the fields of a caught error.
+func evalErrorValueIndexExpression(errorValue *object.ErrorValue, index object.Object) object.Object {
+	field, ok := index.(*object.String)
+
+	if !ok {
+		return newError(fmt.Sprintf("error field must be STRING but get %s", index.GetObjectType()))
+	}
+
+	switch field.Value {
+	case "message":
+		return &object.String{Value: errorValue.Message}
+
+	case "kind":
+		return &object.String{Value: errorValue.Kind}
+
+	case "line":
+		return &object.Integer{Value: int64(errorValue.Span.Start.Line)}
+
+	case "column":
+		return &object.Integer{Value: int64(errorValue.Span.Start.Column)}
+	}
+
+	return NULL
+}
@@ -1,17 +1,34 @@
 package helpers
 
-func IsLetter(char byte) bool {
-	return ('a' <= char && char <= 'z') || ('A' <= char && char <= 'Z') || char == '_'
+import "unicode"
+
+func IsLetter(char rune) bool {
+	return unicode.IsLetter(char) || char == '_'
 }
 
-func IsStringLetter(char byte) bool {
-	return char != '"'
+// IsIdentifierCharacter reports whether char may continue an identifier.
+// Following UAX #31, the characters after the first may also be digits,
+// combining marks and connector punctuation of any script.
+func IsIdentifierCharacter(char rune) bool {
+	return IsLetter(char) || unicode.IsDigit(char) || unicode.In(char, unicode.Mn, unicode.Mc, unicode.Pc)
 }
 
-func IsWhiteSpace(char byte) bool {
+func IsWhiteSpace(char rune) bool {
 	return char == ' ' || char == '\t' || char == '\n' || char == '\r'
 }
 
-func IsDigit(char byte) bool {
+func IsDigit(char rune) bool {
 	return '0' <= char && char <= '9'
 }
+
+func IsHexDigit(char rune) bool {
+	return IsDigit(char) || ('a' <= char && char <= 'f') || ('A' <= char && char <= 'F')
+}
+
+func IsOctalDigit(char rune) bool {
+	return '0' <= char && char <= '7'
+}
+
+func IsBinaryDigit(char rune) bool {
+	return char == '0' || char == '1'
+}
@@ -1,36 +1,151 @@
 package lexer
 
 import (
+	"compiler/diagnostics"
 	"compiler/helpers"
 	"compiler/token"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
+// READ_SIZE is how many bytes a lexer over a reader asks for at a time.
+const READ_SIZE = 4096
+
 type Lexer struct {
-	input            string
+	// buffer holds the input from offset bufferStart on; a lexer over a
+	// reader fills it on demand and drops what earlier tokens consumed
+	buffer      []byte
+	bufferStart int
+	reader      io.Reader
+	streaming   bool
+	fileName    string
+	// cursor is the byte offset of currentCharacter, width its size in
+	// bytes and column its position on the line counted in characters
 	cursor           int
-	currentCharacter byte
+	width            int
+	currentCharacter rune
+	line             int
+	column           int
+	retainComments   bool
+	illegalCode      string
+	diagnostics      []diagnostics.Diagnostic
 }
 
 func New(input string) *Lexer {
-	return &Lexer{input: input, cursor: -1}
+	return NewWithFile("", input)
+}
+
+func NewWithFile(fileName string, input string) *Lexer {
+	return &Lexer{buffer: []byte(input), fileName: fileName, line: 1}
+}
+
+// NewWithReader lexes the input while reading it, keeping in memory only the
+// current token and a few characters of lookahead. It produces the same
+// tokens as NewWithFile over the whole input.
+func NewWithReader(fileName string, reader io.Reader) *Lexer {
+	return &Lexer{reader: reader, streaming: true, fileName: fileName, line: 1}
+}
+
+// RetainComments makes the lexer return comments as COMMENT tokens instead of
+// skipping them, so tools like a formatter can preserve them.
+func (lexer *Lexer) RetainComments() {
+	lexer.retainComments = true
+}
+
+func (lexer *Lexer) GetDiagnostics() []diagnostics.Diagnostic {
+	return lexer.diagnostics
 }
 
 func (lexer *Lexer) readNextChar() {
-	if lexer.cursor+1 >= len(lexer.input) {
-		lexer.currentCharacter = 0
-	} else {
-		lexer.currentCharacter = lexer.input[lexer.cursor+1]
+	if lexer.currentCharacter == '\n' {
+		lexer.line += 1
+		lexer.column = 0
 	}
-	lexer.cursor += 1
 
+	lexer.cursor += lexer.width
+	lexer.column += 1
+	lexer.currentCharacter, lexer.width = lexer.decodeChar(lexer.cursor)
+}
+
+// decodeChar returns the character at offset and its size in bytes; past the
+// end of the input it returns 0, which the lexer treats as EOF.
+func (lexer *Lexer) decodeChar(offset int) (rune, int) {
+	lexer.fill(offset + utf8.UTFMax)
+	index := offset - lexer.bufferStart
+
+	if index >= len(lexer.buffer) {
+		return 0, 1
+	}
+
+	return utf8.DecodeRune(lexer.buffer[index:])
+}
+
+func (lexer *Lexer) slice(start, end int) string {
+	return string(lexer.buffer[start-lexer.bufferStart : end-lexer.bufferStart])
+}
+
+// fill reads until the buffer reaches the offset end or the input ends.
+func (lexer *Lexer) fill(end int) {
+	for lexer.reader != nil && lexer.bufferStart+len(lexer.buffer) < end {
+		if cap(lexer.buffer)-len(lexer.buffer) < READ_SIZE {
+			grown := make([]byte, len(lexer.buffer), 2*cap(lexer.buffer)+READ_SIZE)
+			copy(grown, lexer.buffer)
+			lexer.buffer = grown
+		}
+
+		count, err := lexer.reader.Read(lexer.buffer[len(lexer.buffer):cap(lexer.buffer)])
+		lexer.buffer = lexer.buffer[:len(lexer.buffer)+count]
+
+		if err != nil {
+			if err != io.EOF {
+				span := token.Span{Start: lexer.startPosition(), End: lexer.endPosition()}
+				lexer.diagnostics = append(lexer.diagnostics, diagnostics.New(diagnostics.READ_FAILURE, span, fmt.Sprintf("could not read the input: %s", err)))
+			}
+
+			lexer.reader = nil
+		}
+	}
+}
+
+// discard drops the input before offset once enough of it piled up, which
+// is safe between tokens since no token refers back to earlier input.
+func (lexer *Lexer) discard(offset int) {
+	count := offset - lexer.bufferStart
+
+	if !lexer.streaming || count < READ_SIZE {
+		return
+	}
+
+	lexer.buffer = lexer.buffer[:copy(lexer.buffer, lexer.buffer[count:])]
+	lexer.bufferStart = offset
 }
 
 func (lexer *Lexer) ReadNextToken() token.Token {
+	lexer.discard(lexer.cursor)
 	lexer.skipWhiteSpace()
+
+	for lexer.peekChar() == '/' && (lexer.peekSecondChar() == '/' || lexer.peekSecondChar() == '*') {
+		comment := lexer.readComment()
+
+		if comment.Type == token.ILLEGAL {
+			lexer.reportIllegal(comment)
+		}
+
+		if lexer.retainComments || comment.Type == token.ILLEGAL {
+			return comment
+		}
+
+		lexer.skipWhiteSpace()
+	}
+
 	lexer.readNextChar()
 
 	var character = lexer.currentCharacter
 	var nextToken token.Token
+	var start = lexer.startPosition()
 
 	switch character {
 	case ';':
@@ -41,29 +156,36 @@ func (lexer *Lexer) ReadNextToken() token.Token {
 		nextToken = token.New(token.RPAREN, ")")
 	case ',':
 		nextToken = token.New(token.COMMA, ",")
+	case ':':
+		nextToken = token.New(token.COLON, ":")
 	case '+':
-		nextToken = token.New(token.PLUS, "+")
+		nextToken = lexer.readOperator(token.PLUS, token.PLUS_ASSIGN)
 	case '-':
-		nextToken = token.New(token.MINUS, "-")
+		nextToken = lexer.readOperator(token.MINUS, token.MINUS_ASSIGN)
 	case '*':
-		nextToken = token.New(token.ASTERISK, "*")
+		nextToken = lexer.readOperator(token.ASTERISK, token.ASTERISK_ASSIGN)
 	case '/':
-		nextToken = token.New(token.SLASH, "/")
-	case '<':
-		nextToken = token.New(token.LT, "<")
-	case '>':
-		nextToken = token.New(token.GT, ">")
+		nextToken = lexer.readOperator(token.SLASH, token.SLASH_ASSIGN)
+	case '%':
+		nextToken = lexer.readOperator(token.PERCENT, token.PERCENT_ASSIGN)
+	case '^':
+		nextToken = token.New(token.CARET, "^")
 	case '{':
 		nextToken = token.New(token.LBRACE, "{")
 	case '}':
 		nextToken = token.New(token.RBRACE, "}")
+	case '[':
+		nextToken = token.New(token.LBRACKET, "[")
+	case ']':
+		nextToken = token.New(token.RBRACKET, "]")
 	case 0:
 		nextToken = token.New(token.EOF, "")
 
 	case '"':
-		lexer.readNextChar()
-		nextToken = token.New(token.STRING, lexer.readTokenValue(helpers.IsStringLetter))
-		lexer.readNextChar()
+		nextToken = lexer.readString()
+
+	case '`':
+		nextToken = lexer.readRawString()
 
 	case '=':
 		if lexer.peekChar() == '=' {
@@ -72,6 +194,40 @@ func (lexer *Lexer) ReadNextToken() token.Token {
 		} else {
 			nextToken = token.New(token.ASSIGN, "=")
 		}
+	case '<':
+		if lexer.peekChar() == '=' {
+			lexer.readNextChar()
+			nextToken = token.New(token.LT_EQ, "<=")
+		} else if lexer.peekChar() == '<' {
+			lexer.readNextChar()
+			nextToken = token.New(token.SHIFT_LEFT, "<<")
+		} else {
+			nextToken = token.New(token.LT, "<")
+		}
+	case '>':
+		if lexer.peekChar() == '=' {
+			lexer.readNextChar()
+			nextToken = token.New(token.GT_EQ, ">=")
+		} else if lexer.peekChar() == '>' {
+			lexer.readNextChar()
+			nextToken = token.New(token.SHIFT_RIGHT, ">>")
+		} else {
+			nextToken = token.New(token.GT, ">")
+		}
+	case '&':
+		if lexer.peekChar() == '&' {
+			lexer.readNextChar()
+			nextToken = token.New(token.AND, "&&")
+		} else {
+			nextToken = token.New(token.AMPERSAND, "&")
+		}
+	case '|':
+		if lexer.peekChar() == '|' {
+			lexer.readNextChar()
+			nextToken = token.New(token.OR, "||")
+		} else {
+			nextToken = token.New(token.PIPE, "|")
+		}
 	case '!':
 		if lexer.peekChar() == '=' {
 			lexer.readNextChar()
@@ -82,28 +238,340 @@ func (lexer *Lexer) ReadNextToken() token.Token {
 
 	default:
 		if helpers.IsLetter(character) {
-			tokenValue := lexer.readTokenValue(helpers.IsLetter)
+			tokenValue := lexer.readTokenValue(helpers.IsIdentifierCharacter)
 			nextToken = token.New(token.DefineTokenType((tokenValue)), tokenValue)
 		} else if helpers.IsDigit(character) {
-			nextToken = token.New(token.INT, lexer.readTokenValue(helpers.IsDigit))
+			nextToken = lexer.readNumber()
+		} else if character == utf8.RuneError && lexer.width == 1 {
+			nextToken = lexer.newIllegalToken(diagnostics.ILLEGAL_CHARACTER, fmt.Sprintf("invalid UTF-8 byte %#x", lexer.buffer[lexer.cursor-lexer.bufferStart]))
 		} else {
-			nextToken = token.New(token.ILLEGAL, string(lexer.currentCharacter))
+			nextToken = lexer.newIllegalToken(diagnostics.ILLEGAL_CHARACTER, fmt.Sprintf("unexpected character %q", lexer.currentCharacter))
 		}
 	}
 
+	nextToken.Span = token.Span{Start: start, End: lexer.endPosition()}
+
+	if nextToken.Type == token.ILLEGAL {
+		lexer.reportIllegal(nextToken)
+	}
+
 	return nextToken
 }
 
-func (lexer *Lexer) readTokenValue(valueFilter func(byte) bool) string {
+func (lexer *Lexer) newIllegalToken(code string, message string) token.Token {
+	lexer.illegalCode = code
+
+	return token.New(token.ILLEGAL, message)
+}
+
+func (lexer *Lexer) reportIllegal(illegalToken token.Token) {
+	diagnostic := diagnostics.New(lexer.illegalCode, illegalToken.Span, illegalToken.Literal)
+
+	switch lexer.illegalCode {
+	case diagnostics.INVALID_ESCAPE:
+		diagnostic.Notes = []string{"supported escapes are \\n \\t \\r \\0 \\\" \\\\ and \\u{...}"}
+
+	case diagnostics.UNTERMINATED_STRING, diagnostics.UNTERMINATED_COMMENT:
+		diagnostic.Notes = []string{"reached the end of the input"}
+	}
+
+	lexer.diagnostics = append(lexer.diagnostics, diagnostic)
+}
+
+// startPosition is the position of the current character, endPosition the
+// one just after it.
+func (lexer *Lexer) startPosition() token.Position {
+	return token.Position{
+		File:   lexer.fileName,
+		Offset: lexer.cursor,
+		Line:   lexer.line,
+		Column: lexer.column,
+	}
+}
+
+func (lexer *Lexer) endPosition() token.Position {
+	return token.Position{
+		File:   lexer.fileName,
+		Offset: lexer.cursor + lexer.width,
+		Line:   lexer.line,
+		Column: lexer.column + 1,
+	}
+}
+
+func (lexer *Lexer) readComment() token.Token {
+	lexer.readNextChar()
+	start := lexer.startPosition()
+	var comment token.Token
+
+	if lexer.peekChar() == '/' {
+		for lexer.peekChar() != '\n' && lexer.peekChar() != 0 {
+			lexer.readNextChar()
+		}
+
+		comment = token.New(token.COMMENT, lexer.slice(start.Offset, lexer.cursor+lexer.width))
+	} else {
+		lexer.readNextChar()
+		comment = lexer.newIllegalToken(diagnostics.UNTERMINATED_COMMENT, "unterminated block comment")
+
+		for lexer.peekChar() != 0 {
+			lexer.readNextChar()
+
+			if lexer.currentCharacter == '*' && lexer.peekChar() == '/' {
+				lexer.readNextChar()
+				comment = token.New(token.COMMENT, lexer.slice(start.Offset, lexer.cursor+lexer.width))
+				break
+			}
+		}
+	}
+
+	comment.Span = token.Span{Start: start, End: lexer.endPosition()}
+
+	return comment
+}
+
+// readString decodes the escape sequences of a quoted string. An invalid
+// escape does not stop the string, so lexing resumes after its closing quote.
+func (lexer *Lexer) readString() token.Token {
+	var value strings.Builder
+	var errorMessage string
+
+	for {
+		lexer.readNextChar()
+
+		switch lexer.currentCharacter {
+		case 0:
+			return lexer.newIllegalToken(diagnostics.UNTERMINATED_STRING, "unterminated string")
+
+		case '"':
+			if errorMessage != "" {
+				return lexer.newIllegalToken(diagnostics.INVALID_ESCAPE, errorMessage)
+			}
+
+			return token.New(token.STRING, value.String())
+
+		case '\\':
+			lexer.readNextChar()
+			decoded, escapeError := lexer.readEscapeSequence()
+
+			if escapeError != "" && errorMessage == "" {
+				errorMessage = escapeError
+			}
+
+			value.WriteString(decoded)
+
+		default:
+			value.WriteRune(lexer.currentCharacter)
+		}
+	}
+}
+
+func (lexer *Lexer) readEscapeSequence() (string, string) {
+	switch lexer.currentCharacter {
+	case 'n':
+		return "\n", ""
+	case 't':
+		return "\t", ""
+	case 'r':
+		return "\r", ""
+	case '0':
+		return "\x00", ""
+	case '"':
+		return "\"", ""
+	case '\\':
+		return "\\", ""
+	case 'u':
+		return lexer.readUnicodeEscape()
+	case 0:
+		return "", "unterminated string"
+	default:
+		return "", fmt.Sprintf("unknown escape sequence \\%c", lexer.currentCharacter)
+	}
+}
+
+func (lexer *Lexer) readUnicodeEscape() (string, string) {
+	if lexer.peekChar() != '{' {
+		return "", "unicode escape must look like \\u{...}"
+	}
+
+	lexer.readNextChar()
+	digitsStart := lexer.cursor + lexer.width
+
+	for helpers.IsHexDigit(lexer.peekChar()) {
+		lexer.readNextChar()
+	}
+
+	digits := lexer.slice(digitsStart, lexer.cursor+lexer.width)
+
+	if lexer.peekChar() != '}' {
+		return "", "unicode escape must look like \\u{...}"
+	}
+
+	lexer.readNextChar()
+	codePoint, err := strconv.ParseUint(digits, 16, 32)
+
+	if err != nil || len(digits) > 6 || !utf8.ValidRune(rune(codePoint)) {
+		return "", fmt.Sprintf("invalid unicode escape \\u{%s}", digits)
+	}
+
+	return string(rune(codePoint)), ""
+}
+
+func (lexer *Lexer) readRawString() token.Token {
+	valueStart := lexer.cursor + lexer.width
+
+	for lexer.peekChar() != '`' {
+		if lexer.peekChar() == 0 {
+			lexer.readNextChar()
+			return lexer.newIllegalToken(diagnostics.UNTERMINATED_STRING, "unterminated raw string")
+		}
+
+		lexer.readNextChar()
+	}
+
+	lexer.readNextChar()
+
+	return token.New(token.STRING, lexer.slice(valueStart, lexer.cursor))
+}
+
+type numberBase struct {
+	name    string
+	isDigit func(rune) bool
+}
+
+var numberBases = map[rune]numberBase{
+	'x': {"hexadecimal", helpers.IsHexDigit},
+	'X': {"hexadecimal", helpers.IsHexDigit},
+	'o': {"octal", helpers.IsOctalDigit},
+	'O': {"octal", helpers.IsOctalDigit},
+	'b': {"binary", helpers.IsBinaryDigit},
+	'B': {"binary", helpers.IsBinaryDigit},
+}
+
+// readNumber reads an integer literal, a float literal when the digits go
+// on with a fraction or an exponent, or a big integer one with an n suffix.
+// Underscores may separate digits, as in 1_000_000.
+func (lexer *Lexer) readNumber() token.Token {
+	if base, ok := numberBases[lexer.peekChar()]; ok && lexer.currentCharacter == '0' {
+		return lexer.readPrefixedNumber(base)
+	}
+
+	numberStart := lexer.cursor
+	numberType := token.TokenType(token.INT)
+
+	lexer.readDigits(helpers.IsDigit)
+
+	if lexer.peekChar() == '.' && helpers.IsDigit(lexer.peekSecondChar()) {
+		numberType = token.FLOAT
+		lexer.readNextChar()
+		lexer.readDigits(helpers.IsDigit)
+	}
+
+	if lexer.peekChar() == 'e' || lexer.peekChar() == 'E' {
+		numberType = token.FLOAT
+		lexer.readNextChar()
+
+		if lexer.peekChar() == '+' || lexer.peekChar() == '-' {
+			lexer.readNextChar()
+		}
+
+		if !helpers.IsDigit(lexer.peekChar()) {
+			return lexer.newIllegalToken(diagnostics.INVALID_NUMBER, fmt.Sprintf("missing exponent digits in %s", lexer.slice(numberStart, lexer.cursor+lexer.width)))
+		}
+
+		lexer.readDigits(helpers.IsDigit)
+	}
+
+	if lexer.peekChar() == 'n' {
+		lexer.readNextChar()
+
+		if numberType == token.FLOAT {
+			return lexer.newIllegalToken(diagnostics.INVALID_NUMBER, fmt.Sprintf("bigint literal %s can not have a fraction or an exponent", lexer.slice(numberStart, lexer.cursor+lexer.width)))
+		}
+
+		numberType = token.BIGINT
+	}
+
+	literal := lexer.slice(numberStart, lexer.cursor+lexer.width)
+
+	// a leading zero means octal in other languages, so 010 is rejected
+	// rather than read as either eight or ten
+	if numberType != token.FLOAT && literal[0] == '0' && len(literal) > 1 && literal[1] != 'n' {
+		return lexer.newIllegalToken(diagnostics.INVALID_NUMBER, fmt.Sprintf("leading zero in %s; octal numbers take the 0o prefix", literal))
+	}
+
+	if !hasValidSeparators(literal, helpers.IsDigit) {
+		return lexer.newIllegalToken(diagnostics.INVALID_NUMBER, fmt.Sprintf("invalid digit separator in %s", literal))
+	}
+
+	return token.New(numberType, literal)
+}
+
+// readPrefixedNumber reads every letter and digit following a 0x, 0o or 0b
+// prefix, so that a stray character is reported as part of the literal
+// rather than starting the next token.
+func (lexer *Lexer) readPrefixedNumber(base numberBase) token.Token {
+	numberStart := lexer.cursor
+	lexer.readNextChar()
+
+	for helpers.IsLetter(lexer.peekChar()) || helpers.IsDigit(lexer.peekChar()) {
+		lexer.readNextChar()
+	}
+
+	literal := lexer.slice(numberStart, lexer.cursor+lexer.width)
+	digits := strings.TrimSuffix(literal[2:], "n")
+	numberType := token.TokenType(token.INT)
+
+	if len(digits) < len(literal)-2 {
+		numberType = token.BIGINT
+	}
+
+	if digits == "" {
+		return lexer.newIllegalToken(diagnostics.INVALID_NUMBER, fmt.Sprintf("%s literal %s has no digits", base.name, literal))
+	}
+
+	for _, digit := range digits {
+		if digit != '_' && !base.isDigit(digit) {
+			return lexer.newIllegalToken(diagnostics.INVALID_NUMBER, fmt.Sprintf("invalid digit %q in %s literal %s", digit, base.name, literal))
+		}
+	}
+
+	if !hasValidSeparators(literal, base.isDigit) {
+		return lexer.newIllegalToken(diagnostics.INVALID_NUMBER, fmt.Sprintf("invalid digit separator in %s", literal))
+	}
+
+	return token.New(numberType, literal)
+}
+
+func (lexer *Lexer) readDigits(isDigit func(rune) bool) {
+	for isDigit(lexer.peekChar()) || lexer.peekChar() == '_' {
+		lexer.readNextChar()
+	}
+}
+
+// hasValidSeparators reports whether every underscore of literal sits
+// between two digits.
+func hasValidSeparators(literal string, isDigit func(rune) bool) bool {
+	for index := 0; index < len(literal); index++ {
+		if literal[index] != '_' {
+			continue
+		}
+
+		if index == 0 || index == len(literal)-1 || !isDigit(rune(literal[index-1])) || !isDigit(rune(literal[index+1])) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (lexer *Lexer) readTokenValue(valueFilter func(rune) bool) string {
 	stringStartPosition := lexer.cursor
 
 	for valueFilter(lexer.peekChar()) {
 		lexer.readNextChar()
 	}
 
-	stringEndPosition := lexer.cursor + 1
-
-	return lexer.input[stringStartPosition:stringEndPosition]
+	return lexer.slice(stringStartPosition, lexer.cursor+lexer.width)
 }
 
 func (lexer *Lexer) skipWhiteSpace() {
@@ -112,9 +580,26 @@ func (lexer *Lexer) skipWhiteSpace() {
 	}
 }
 
-func (lexer *Lexer) peekChar() byte {
-	if lexer.cursor+1 >= len(lexer.input) {
-		return 0
+// readOperator reads the compound assignment form of an operator when the
+// current character is followed by '='.
+func (lexer *Lexer) readOperator(operator, assignOperator token.TokenType) token.Token {
+	if lexer.peekChar() == '=' {
+		lexer.readNextChar()
+		return token.New(assignOperator, string(assignOperator))
 	}
-	return lexer.input[lexer.cursor+1]
+
+	return token.New(operator, string(lexer.currentCharacter))
+}
+
+func (lexer *Lexer) peekChar() rune {
+	character, _ := lexer.decodeChar(lexer.cursor + lexer.width)
+
+	return character
+}
+
+func (lexer *Lexer) peekSecondChar() rune {
+	_, width := lexer.decodeChar(lexer.cursor + lexer.width)
+	character, _ := lexer.decodeChar(lexer.cursor + lexer.width + width)
+
+	return character
 }
@@ -0,0 +1,115 @@
+package lexer
+
+import (
+	"compiler/token"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func readTokens(lexer *Lexer) []token.Token {
+	var tokens []token.Token
+
+	for {
+		nextToken := lexer.ReadNextToken()
+		tokens = append(tokens, nextToken)
+
+		if nextToken.Type == token.EOF {
+			return tokens
+		}
+	}
+}
+
+// TestReaderMatchesString lexes each input from a string and from readers
+// handing it over in pieces, which must produce the same tokens.
+func TestReaderMatchesString(t *testing.T) {
+	program := `let größe = fn(x, y) { x + y * 0x_ff - 1_000n; }; // Kommentar
+let s = "héllo \u{1F600} 世界"; /* block
+comment */ if (größe(1.5e3, 2) >= 10) { return [1, 2][0]; } @
+`
+
+	inputs := []string{
+		"",
+		program,
+		strings.Repeat(program, 3*READ_SIZE/len(program)+1),
+		// a multibyte rune straddling the end of the first read
+		strings.Repeat("a", READ_SIZE-1) + " 世界 \"ü\"",
+		"\"unterminated",
+		"let x = 1; \xff",
+	}
+
+	readers := map[string]func(io.Reader) io.Reader{
+		"whole":    func(reader io.Reader) io.Reader { return reader },
+		"one byte": iotest.OneByteReader,
+		"half":     iotest.HalfReader,
+	}
+
+	for _, input := range inputs {
+		expected := readTokens(NewWithFile("test", input))
+
+		for name, wrap := range readers {
+			got := readTokens(NewWithReader("test", wrap(strings.NewReader(input))))
+
+			if len(got) != len(expected) {
+				t.Errorf("%s reader: got %d tokens, want %d", name, len(got), len(expected))
+				continue
+			}
+
+			for index := range expected {
+				if got[index] != expected[index] {
+					t.Errorf("%s reader: token %d is %+v, want %+v", name, index, got[index], expected[index])
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestUnicodeIdentifiers(t *testing.T) {
+	// Devanagari vowel signs and the NFD form of "café" are combining marks
+	identifiers := []string{"größe", "नाम", "cafe\u0301", "a‿b", "x_1", "x٣"}
+
+	for _, identifier := range identifiers {
+		tokens := readTokens(New(identifier))
+
+		if len(tokens) != 2 || tokens[0].Type != token.IDENT || tokens[0].Literal != identifier {
+			t.Errorf("%q: got tokens %+v, want a single identifier", identifier, tokens)
+		}
+	}
+
+	if tokens := readTokens(New("\u0301a")); tokens[0].Type != token.ILLEGAL {
+		t.Errorf("a combining mark can not start an identifier, got %+v", tokens[0])
+	}
+}
+
+func TestMalformedNumbers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"010", "leading zero in 010; octal numbers take the 0o prefix"},
+		{"0_10", "leading zero in 0_10; octal numbers take the 0o prefix"},
+		{"09", "leading zero in 09; octal numbers take the 0o prefix"},
+		{"010n", "leading zero in 010n; octal numbers take the 0o prefix"},
+		{"1__0", "invalid digit separator in 1__0"},
+		{"1e", "missing exponent digits in 1e"},
+		{"1.5n", "bigint literal 1.5n can not have a fraction or an exponent"},
+		{"1e3n", "bigint literal 1e3n can not have a fraction or an exponent"},
+		{"0x", "hexadecimal literal 0x has no digits"},
+	}
+
+	for _, test := range tests {
+		tokens := readTokens(New(test.input))
+
+		if len(tokens) != 2 || tokens[0].Type != token.ILLEGAL || tokens[0].Literal != test.expected {
+			t.Errorf("%q: got tokens %+v, want %q", test.input, tokens, test.expected)
+		}
+	}
+
+	for _, input := range []string{"0", "0n", "0.5", "0e3", "10", "0o10"} {
+		if tokens := readTokens(New(input)); len(tokens) != 2 || tokens[0].Type == token.ILLEGAL {
+			t.Errorf("%q: got tokens %+v, want a single number", input, tokens)
+		}
+	}
+}
@@ -1,20 +1,10 @@
 package main
 
 import (
-	"compiler/repl"
-	"fmt"
+	"compiler/cli"
 	"os"
-	"os/user"
 )
 
 func main() {
-	currentUser, err := user.Current()
-
-	if err != nil {
-		panic(err)
-	}
-
-	fmt.Printf("Hello %s! This is REPL, feel fre to input any command\n", currentUser.Username)
-	repl.Start(os.Stdin, os.Stdout)
-
+	os.Exit(cli.Run(os.Args[1:], cli.Streams{In: os.Stdin, Out: os.Stdout, Err: os.Stderr}))
 }
@@ -0,0 +1,30 @@
+package object
+
+import (
+	"bytes"
+	"strings"
+)
+
+type Array struct {
+	Elements []Object
+}
+
+func (arrayObj *Array) Inspect() string {
+	var output bytes.Buffer
+
+	elements := []string{}
+
+	for _, element := range arrayObj.Elements {
+		elements = append(elements, element.Inspect())
+	}
+
+	output.WriteString("[")
+	output.WriteString(strings.Join(elements, ", "))
+	output.WriteString("]")
+
+	return output.String()
+}
+
+func (arrayObj *Array) GetObjectType() ObjectType {
+	return ARRAY_OBJ
+}
@@ -0,0 +1,19 @@
+package object
+
+import "math/big"
+
+// BigInt is an arbitrary-precision integer; operations always allocate a
+// new Value, so a BigInt shared between bindings is never mutated.
+type BigInt struct {
+	Value *big.Int
+}
+
+// Inspect writes the n suffix of the literal, telling a BigInt apart from an
+// Integer of the same value.
+func (bigIntObj *BigInt) Inspect() string {
+	return bigIntObj.Value.String() + "n"
+}
+
+func (bigIntObj *BigInt) GetObjectType() ObjectType {
+	return BIGINT_OBJ
+}
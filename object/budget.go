@@ -0,0 +1,62 @@
+package object
+
+import (
+	"context"
+	"fmt"
+)
+
+// BUDGET_CHECK_INTERVAL is how many steps pass between two looks at the
+// context, which cost far more than counting a step.
+const BUDGET_CHECK_INTERVAL = 1024
+
+// Budget bounds a running evaluation by a context and a number of steps.
+// It is inactive, and costs a single comparison per step, outside of Start
+// and Stop.
+type Budget struct {
+	context  context.Context
+	maxSteps int64
+	steps    int64
+	active   bool
+}
+
+// Start activates the budget; a maxSteps of zero leaves the steps uncounted.
+func (budget *Budget) Start(ctx context.Context, maxSteps int64) {
+	budget.context = ctx
+	budget.maxSteps = maxSteps
+	budget.steps = 0
+	budget.active = true
+}
+
+func (budget *Budget) Stop() {
+	budget.context = nil
+	budget.active = false
+}
+
+// Spend counts a step and returns the error that aborts the evaluation once
+// the budget is exhausted, nil until then.
+func (budget *Budget) Spend() *Error {
+	if !budget.active {
+		return nil
+	}
+
+	budget.steps++
+
+	if budget.maxSteps > 0 && budget.steps > budget.maxSteps {
+		return &Error{Message: fmt.Sprintf("evaluation exceeded %d steps", budget.maxSteps), Kind: STEP_LIMIT_KIND, abort: true}
+	}
+
+	if budget.steps%BUDGET_CHECK_INTERVAL != 1 {
+		return nil
+	}
+
+	switch budget.context.Err() {
+	case nil:
+		return nil
+
+	case context.DeadlineExceeded:
+		return &Error{Message: "evaluation deadline exceeded", Kind: DEADLINE_EXCEEDED_KIND, abort: true}
+
+	default:
+		return &Error{Message: "evaluation canceled", Kind: CANCELED_KIND, abort: true}
+	}
+}
@@ -0,0 +1,43 @@
+package object
+
+import "compiler/token"
+
+// CallFrame is a function call in progress: the function called and the
+// position of the call expression.
+type CallFrame struct {
+	Function string
+	Span     token.Span
+}
+
+// CallStack holds the calls the evaluator is inside of, the outermost first.
+type CallStack struct {
+	frames []CallFrame
+}
+
+func (callStack *CallStack) Push(frame CallFrame) {
+	callStack.frames = append(callStack.frames, frame)
+}
+
+func (callStack *CallStack) Pop() {
+	callStack.frames = callStack.frames[:len(callStack.frames)-1]
+}
+
+func (callStack *CallStack) Depth() int {
+	return len(callStack.frames)
+}
+
+// Trace copies the frames, the most recent call first, or returns nil
+// outside of any call.
+func (callStack *CallStack) Trace() []CallFrame {
+	if len(callStack.frames) == 0 {
+		return nil
+	}
+
+	trace := make([]CallFrame, len(callStack.frames))
+
+	for index, frame := range callStack.frames {
+		trace[len(trace)-1-index] = frame
+	}
+
+	return trace
+}
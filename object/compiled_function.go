@@ -0,0 +1,37 @@
+package object
+
+import (
+	"compiler/token"
+	"fmt"
+)
+
+type CompiledFunction struct {
+	Instructions  []byte
+	NumLocals     int
+	NumParameters int
+	Name          string
+	// Positions maps the offset of each instruction that can raise an error
+	// to the source it was compiled from
+	Positions map[int]token.Span
+}
+
+func (compiledFunctionObj *CompiledFunction) Inspect() string {
+	return fmt.Sprintf("compiled function[%p]", compiledFunctionObj)
+}
+
+func (compiledFunctionObj *CompiledFunction) GetObjectType() ObjectType {
+	return COMPILED_FUNCTION_OBJ
+}
+
+type Closure struct {
+	Fn   *CompiledFunction
+	Free []Object
+}
+
+func (closureObj *Closure) Inspect() string {
+	return fmt.Sprintf("closure[%p]", closureObj)
+}
+
+func (closureObj *Closure) GetObjectType() ObjectType {
+	return CLOSURE_OBJ
+}
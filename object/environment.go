@@ -1,13 +1,42 @@
 package object
 
+// DEFAULT_MAX_CALL_DEPTH bounds the calls in progress when Settings leaves
+// MaxCallDepth at zero.
+const DEFAULT_MAX_CALL_DEPTH = 10000
+
+type Settings struct {
+	CheckedArithmetic bool
+	MaxCallDepth      int
+}
+
+func (settings *Settings) CallDepthLimit() int {
+	if settings.MaxCallDepth <= 0 {
+		return DEFAULT_MAX_CALL_DEPTH
+	}
+
+	return settings.MaxCallDepth
+}
+
 type Environment struct {
-	store map[string]Object
-	outer *Environment
+	store     map[string]Object
+	constants map[string]bool
+	outer     *Environment
+	settings  *Settings
+	callStack *CallStack
+	budget    *Budget
 }
 
 func NewEnvironment() *Environment {
+	return NewEnvironmentWithSettings(&Settings{})
+}
+
+func NewEnvironmentWithSettings(settings *Settings) *Environment {
 	return &Environment{
-		store: make(map[string]Object),
+		store:     make(map[string]Object),
+		constants: make(map[string]bool),
+		settings:  settings,
+		callStack: &CallStack{},
+		budget:    &Budget{},
 	}
 }
 
@@ -27,9 +56,66 @@ func (environmentObj *Environment) Set(name string, value Object) Object {
 	return value
 }
 
+func (environmentObj *Environment) SetConstant(name string, value Object) Object {
+	environmentObj.constants[name] = true
+
+	return environmentObj.Set(name, value)
+}
+
+// IsConstant reports whether the binding that name resolves to, in this
+// environment or an outer one, was declared with const.
+func (environmentObj *Environment) IsConstant(name string) bool {
+	if _, exist := environmentObj.store[name]; exist {
+		return environmentObj.constants[name]
+	}
+
+	if environmentObj.outer != nil {
+		return environmentObj.outer.IsConstant(name)
+	}
+
+	return false
+}
+
+// DeclaresConstant reports whether this environment itself, not an outer
+// one, holds a const binding for name.
+func (environmentObj *Environment) DeclaresConstant(name string) bool {
+	return environmentObj.constants[name]
+}
+
+// Assign overwrites an existing binding in the innermost environment that
+// declares name and reports whether such a binding was found.
+func (environmentObj *Environment) Assign(name string, value Object) bool {
+	if _, exist := environmentObj.store[name]; exist {
+		environmentObj.store[name] = value
+		return true
+	}
+
+	if environmentObj.outer != nil {
+		return environmentObj.outer.Assign(name, value)
+	}
+
+	return false
+}
+
+func (environmentObj *Environment) Settings() *Settings {
+	return environmentObj.settings
+}
+
+// CallStack is shared by every environment extended from the same one.
+func (environmentObj *Environment) CallStack() *CallStack {
+	return environmentObj.callStack
+}
+
+// Budget is shared the same way as the call stack.
+func (environmentObj *Environment) Budget() *Budget {
+	return environmentObj.budget
+}
+
 func (environmentObj *Environment) Extend() *Environment {
-	extendedEnvironemtObj := NewEnvironment()
+	extendedEnvironemtObj := NewEnvironmentWithSettings(environmentObj.settings)
 	extendedEnvironemtObj.outer = environmentObj
+	extendedEnvironemtObj.callStack = environmentObj.callStack
+	extendedEnvironemtObj.budget = environmentObj.budget
 
 	return extendedEnvironemtObj
 }
@@ -1,13 +1,116 @@
 package object
 
+import (
+	"bytes"
+	"compiler/diagnostics"
+	"compiler/token"
+	"fmt"
+)
+
+// MAX_TRACE_FRAMES is how many of the most recent calls StackTrace lists.
+const MAX_TRACE_FRAMES = 20
+
+// kinds of errors: the runtime raises RUNTIME_ERROR_KIND ones, scripts
+// raise ERROR_KIND ones unless they pass their own kind to error()
+const (
+	RUNTIME_ERROR_KIND = "RuntimeError"
+	ERROR_KIND         = "Error"
+)
+
+// kinds of the errors that abort an evaluation when its Budget runs out;
+// catch clauses let them through
+const (
+	CANCELED_KIND          = "Canceled"
+	DEADLINE_EXCEEDED_KIND = "DeadlineExceeded"
+	STEP_LIMIT_KIND        = "StepLimitExceeded"
+)
+
 type Error struct {
 	Message string
+	Kind    string
+	Span    token.Span
+	// Trace lists the calls the error was raised in, the most recent first
+	Trace []CallFrame
+
+	abort bool
 }
 
 func (errorObj *Error) Inspect() string {
-	return "Error: " + errorObj.Message
+	if errorObj.Span.Start.IsValid() {
+		return "Error: " + errorObj.Span.Start.ToString() + ": " + errorObj.describe()
+	}
+
+	return "Error: " + errorObj.describe()
+}
+
+// describe names the kind of the errors given one with error(msg, kind);
+// the other errors read as before.
+func (errorObj *Error) describe() string {
+	if errorObj.Kind == "" || errorObj.Kind == RUNTIME_ERROR_KIND || errorObj.Kind == ERROR_KIND {
+		return errorObj.Message
+	}
+
+	return errorObj.Kind + ": " + errorObj.Message
+}
+
+// IsAbort reports whether a Budget raised the error; scripts can not raise
+// such errors themselves, whatever kind they give them.
+func (errorObj *Error) IsAbort() bool {
+	return errorObj.abort
+}
+
+// IsReservedKind reports whether kind belongs to the errors a Budget raises.
+func IsReservedKind(kind string) bool {
+	switch kind {
+	case CANCELED_KIND, DEADLINE_EXCEEDED_KIND, STEP_LIMIT_KIND:
+		return true
+	}
+
+	return false
 }
 
 func (errorObj *Error) GetObjectType() ObjectType {
 	return ERROR_OBJ
 }
+
+// StackTrace renders the calls the error was raised in the way a Go panic
+// does: each function with the position reached in it, the most recent
+// first, ending with the program itself. It is empty outside of any call.
+func (errorObj *Error) StackTrace() string {
+	if len(errorObj.Trace) == 0 {
+		return ""
+	}
+
+	var output bytes.Buffer
+
+	output.WriteString("call stack (most recent call first):\n")
+	position := errorObj.Span.Start
+
+	for index, frame := range errorObj.Trace {
+		if index == MAX_TRACE_FRAMES {
+			fmt.Fprintf(&output, "...%d additional frames elided...\n", len(errorObj.Trace)-index)
+			break
+		}
+
+		writeTraceEntry(&output, frame.Function+"(...)", position)
+		position = frame.Span.Start
+	}
+
+	position = errorObj.Trace[len(errorObj.Trace)-1].Span.Start
+
+	writeTraceEntry(&output, "<program>", position)
+
+	return output.String()
+}
+
+func writeTraceEntry(output *bytes.Buffer, function string, position token.Position) {
+	output.WriteString(function + "\n")
+
+	if position.IsValid() {
+		output.WriteString("\t" + position.ToString() + "\n")
+	}
+}
+
+func (errorObj *Error) ToDiagnostic() diagnostics.Diagnostic {
+	return diagnostics.New(diagnostics.RUNTIME_ERROR, errorObj.Span, errorObj.describe())
+}
@@ -0,0 +1,24 @@
+package object
+
+import "compiler/token"
+
+// ErrorValue is an error a script holds as a value, from error() or from a
+// catch clause, as opposed to an Error, which propagates until caught.
+type ErrorValue struct {
+	Message string
+	Kind    string
+	Span    token.Span
+	Trace   []CallFrame
+}
+
+func (errorValueObj *ErrorValue) Inspect() string {
+	if errorValueObj.Span.Start.IsValid() {
+		return errorValueObj.Kind + ": " + errorValueObj.Span.Start.ToString() + ": " + errorValueObj.Message
+	}
+
+	return errorValueObj.Kind + ": " + errorValueObj.Message
+}
+
+func (errorValueObj *ErrorValue) GetObjectType() ObjectType {
+	return ERROR_VALUE_OBJ
+}
@@ -0,0 +1,26 @@
+package object
+
+import (
+	"strconv"
+	"strings"
+)
+
+type Float struct {
+	Value float64
+}
+
+// Inspect keeps a fraction on whole values, so 2.0 never reads as the
+// integer 2.
+func (floatObj *Float) Inspect() string {
+	text := strconv.FormatFloat(floatObj.Value, 'g', -1, 64)
+
+	if strings.ContainsAny(text, ".eIN") {
+		return text
+	}
+
+	return text + ".0"
+}
+
+func (floatObj *Float) GetObjectType() ObjectType {
+	return FLOAT_OBJ
+}
@@ -7,6 +7,7 @@ import (
 )
 
 type Function struct {
+	Name        string
 	Parameters  []*ast.Identifier
 	Body        *ast.BlockStatement
 	Environment *Environment
@@ -22,6 +23,11 @@ func (functionObj *Function) Inspect() string {
 		parameters = append(parameters, parameter.ToString())
 	}
 	output.WriteString("fn")
+
+	if functionObj.Name != "" {
+		output.WriteString(" " + functionObj.Name)
+	}
+
 	output.WriteString("(")
 	output.WriteString(strings.Join(parameters, ", "))
 	output.WriteString(") {\n")
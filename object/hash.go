@@ -0,0 +1,96 @@
+package object
+
+import (
+	"bytes"
+	"strings"
+)
+
+// HashKey identifies a key by value: Value for the keys that fit 64 bits,
+// Text for the others, so two different keys never share a HashKey.
+type HashKey struct {
+	Type  ObjectType
+	Value uint64
+	Text  string
+}
+
+type Hashable interface {
+	Object
+	HashKey() HashKey
+}
+
+func (integerObj *Integer) HashKey() HashKey {
+	return HashKey{Type: integerObj.GetObjectType(), Value: uint64(integerObj.Value)}
+}
+
+func (booleanObj *Boolean) HashKey() HashKey {
+	if booleanObj.Value {
+		return HashKey{Type: booleanObj.GetObjectType(), Value: 1}
+	}
+
+	return HashKey{Type: booleanObj.GetObjectType(), Value: 0}
+}
+
+// HashKey of a big integer that fits 64 bits is the key of the equal
+// Integer, since the two compare equal.
+func (bigIntObj *BigInt) HashKey() HashKey {
+	if bigIntObj.Value.IsInt64() {
+		return (&Integer{Value: bigIntObj.Value.Int64()}).HashKey()
+	}
+
+	return HashKey{Type: bigIntObj.GetObjectType(), Text: bigIntObj.Value.Text(16)}
+}
+
+func (stringObj *String) HashKey() HashKey {
+	return HashKey{Type: stringObj.GetObjectType(), Text: stringObj.Value}
+}
+
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+type Hash struct {
+	Pairs map[HashKey]HashPair
+	keys  []HashKey
+}
+
+func NewHash() *Hash {
+	return &Hash{Pairs: make(map[HashKey]HashPair)}
+}
+
+func (hashObj *Hash) Get(key Hashable) (Object, bool) {
+	pair, exist := hashObj.Pairs[key.HashKey()]
+
+	return pair.Value, exist
+}
+
+func (hashObj *Hash) Set(key Hashable, value Object) {
+	hashKey := key.HashKey()
+
+	if _, exist := hashObj.Pairs[hashKey]; !exist {
+		hashObj.keys = append(hashObj.keys, hashKey)
+	}
+
+	hashObj.Pairs[hashKey] = HashPair{Key: key, Value: value}
+}
+
+func (hashObj *Hash) Inspect() string {
+	var output bytes.Buffer
+
+	pairs := []string{}
+
+	for _, hashKey := range hashObj.keys {
+		pair := hashObj.Pairs[hashKey]
+		pairs = append(pairs, pair.Key.Inspect()+": "+pair.Value.Inspect())
+	}
+
+	output.WriteString("{")
+	output.WriteString(strings.Join(pairs, ", "))
+	output.WriteString("}")
+
+	return output.String()
+}
+
+func (hashObj *Hash) GetObjectType() ObjectType {
+	return HASH_OBJ
+}
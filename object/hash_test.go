@@ -0,0 +1,35 @@
+package object
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestHashKeysCompareByValue(t *testing.T) {
+	large := new(big.Int).Lsh(big.NewInt(1), 80)
+
+	equal := [][2]Hashable{
+		{&String{Value: "key"}, &String{Value: "key"}},
+		{&Integer{Value: 1}, &BigInt{Value: big.NewInt(1)}},
+		{&BigInt{Value: large}, &BigInt{Value: new(big.Int).Set(large)}},
+	}
+
+	for _, keys := range equal {
+		if keys[0].HashKey() != keys[1].HashKey() {
+			t.Errorf("%s and %s should share a hash key", keys[0].Inspect(), keys[1].Inspect())
+		}
+	}
+
+	different := [][2]Hashable{
+		{&String{Value: "a"}, &String{Value: "b"}},
+		{&String{Value: "1"}, &Integer{Value: 1}},
+		{&Integer{Value: 1}, &Boolean{Value: true}},
+		{&BigInt{Value: large}, &BigInt{Value: new(big.Int).Add(large, big.NewInt(1))}},
+	}
+
+	for _, keys := range different {
+		if keys[0].HashKey() == keys[1].HashKey() {
+			t.Errorf("%s and %s should not share a hash key", keys[0].Inspect(), keys[1].Inspect())
+		}
+	}
+}
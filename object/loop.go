@@ -0,0 +1,21 @@
+package object
+
+type Break struct{}
+
+func (breakObj *Break) Inspect() string {
+	return "break"
+}
+
+func (breakObj *Break) GetObjectType() ObjectType {
+	return BREAK_OBJ
+}
+
+type Continue struct{}
+
+func (continueObj *Continue) Inspect() string {
+	return "continue"
+}
+
+func (continueObj *Continue) GetObjectType() ObjectType {
+	return CONTINUE_OBJ
+}
@@ -0,0 +1,19 @@
+package object
+
+import "compiler/ast"
+
+// TailCall is a call a function body ends with, handed back to the caller
+// of the function to make in its place.
+type TailCall struct {
+	Function  *Function
+	Arguments []Object
+	Call      *ast.CallExpression
+}
+
+func (tailCallObj *TailCall) Inspect() string {
+	return tailCallObj.Call.ToString()
+}
+
+func (tailCallObj *TailCall) GetObjectType() ObjectType {
+	return TAIL_CALL_OBJ
+}
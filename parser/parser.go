@@ -2,38 +2,91 @@ package parser
 
 import (
 	"compiler/ast"
+	"compiler/diagnostics"
 	"compiler/lexer"
 	"compiler/token"
+	"errors"
 	"fmt"
+	"math/big"
 	"strconv"
+	"strings"
 )
 
 const (
 	_ int = iota
 	LOWEST
+	ASSIGNMENT
+	LOGICAL_OR
+	LOGICAL_AND
+	BITWISE_OR
+	BITWISE_XOR
+	BITWISE_AND
 	EQUALS
 	LESSGREATER
+	SHIFT
 	SUM
 	PRODUCT
 	PREFIX
 	CALL
+	INDEX
 )
 
+const MAX_ERRORS = 20
+
 type (
 	prefixParseFn func() ast.Expression
 	infixParseFn  func(ast.Expression) ast.Expression
 )
 
+var insertableTokens = map[token.TokenType]string{
+	token.RPAREN:    ")",
+	token.RBRACKET:  "]",
+	token.RBRACE:    "}",
+	token.LPAREN:    "(",
+	token.LBRACE:    "{",
+	token.COLON:     ":",
+	token.SEMICOLON: ";",
+	token.ASSIGN:    "=",
+}
+
+var statementKeywords = map[token.TokenType]bool{
+	token.LET:      true,
+	token.CONST:    true,
+	token.RETURN:   true,
+	token.WHILE:    true,
+	token.FOR:      true,
+	token.BREAK:    true,
+	token.CONTINUE: true,
+	token.THROW:    true,
+}
+
 var precedences = map[token.TokenType]int{
-	token.EQ:       EQUALS,
-	token.NOT_EQ:   EQUALS,
-	token.LT:       LESSGREATER,
-	token.GT:       LESSGREATER,
-	token.PLUS:     SUM,
-	token.MINUS:    SUM,
-	token.SLASH:    PRODUCT,
-	token.ASTERISK: PRODUCT,
-	token.LPAREN:   CALL,
+	token.ASSIGN:          ASSIGNMENT,
+	token.PLUS_ASSIGN:     ASSIGNMENT,
+	token.MINUS_ASSIGN:    ASSIGNMENT,
+	token.ASTERISK_ASSIGN: ASSIGNMENT,
+	token.SLASH_ASSIGN:    ASSIGNMENT,
+	token.PERCENT_ASSIGN:  ASSIGNMENT,
+	token.OR:              LOGICAL_OR,
+	token.AND:             LOGICAL_AND,
+	token.PIPE:            BITWISE_OR,
+	token.CARET:           BITWISE_XOR,
+	token.AMPERSAND:       BITWISE_AND,
+	token.EQ:              EQUALS,
+	token.NOT_EQ:          EQUALS,
+	token.LT:              LESSGREATER,
+	token.GT:              LESSGREATER,
+	token.LT_EQ:           LESSGREATER,
+	token.GT_EQ:           LESSGREATER,
+	token.SHIFT_LEFT:      SHIFT,
+	token.SHIFT_RIGHT:     SHIFT,
+	token.PLUS:            SUM,
+	token.MINUS:           SUM,
+	token.SLASH:           PRODUCT,
+	token.ASTERISK:        PRODUCT,
+	token.PERCENT:         PRODUCT,
+	token.LPAREN:          CALL,
+	token.LBRACKET:        INDEX,
 }
 
 type Parser struct {
@@ -42,11 +95,31 @@ type Parser struct {
 	peekToken      token.Token
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
-	errors         []string
+	diagnostics    []diagnostics.Diagnostic
+	// panicking is set by the first error of a statement and silences the
+	// errors cascading from it until the parser synchronizes
+	panicking bool
+	// loopDepth counts the loops around the current statement, within the
+	// current function, to reject break and continue outside of them
+	loopDepth int
+	// valueDepth counts the expressions around the current statement, within
+	// the current loop, whose value is used; a break or continue there would
+	// leave the loop with the expression half evaluated
+	valueDepth int
+	// statementExpression is set while the expression of an expression
+	// statement has not started, since an if or try there may hold break
+	// and continue as long as no operator follows it
+	statementExpression bool
+	// loopControls holds the break and continue statements of the current
+	// loop that are not inside an expression whose value is used
+	loopControls []token.Token
+	// comments holds the comments of a lexer retaining them, in source
+	// order, kept aside since the grammar has no place for them
+	comments []token.Token
 }
 
 func New(lexer *lexer.Lexer) *Parser {
-	parser := &Parser{lexer: lexer, errors: []string{}}
+	parser := &Parser{lexer: lexer, diagnostics: []diagnostics.Diagnostic{}}
 	parser.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	parser.infixParseFns = make(map[token.TokenType]infixParseFn)
 
@@ -59,7 +132,7 @@ func (parser *Parser) ParseProgram() *ast.Program {
 	program := &ast.Program{}
 	program.Statements = []ast.Statement{}
 
-	for !parser.expectCurrentToken(token.EOF) {
+	for !parser.expectCurrentToken(token.EOF) && !parser.hasTooManyErrors() {
 
 		statement := parser.parseStatement()
 
@@ -67,6 +140,10 @@ func (parser *Parser) ParseProgram() *ast.Program {
 			program.Statements = append(program.Statements, statement)
 		}
 
+		if parser.panicking {
+			parser.synchronize()
+		}
+
 		parser.readNextToken()
 	}
 
@@ -74,19 +151,56 @@ func (parser *Parser) ParseProgram() *ast.Program {
 }
 
 func (parser *Parser) GetParsingErrors() []string {
-	return parser.errors
+	errors := []string{}
+
+	for _, diagnostic := range parser.GetDiagnostics() {
+		errors = append(errors, diagnostic.ToString())
+	}
+
+	return errors
+}
+
+// Comments returns the comments skipped while parsing, when the lexer
+// retains them.
+func (parser *Parser) Comments() []token.Token {
+	return parser.comments
+}
+
+// GetDiagnostics returns the lexer and parser diagnostics in source order.
+func (parser *Parser) GetDiagnostics() []diagnostics.Diagnostic {
+	allDiagnostics := append([]diagnostics.Diagnostic{}, parser.lexer.GetDiagnostics()...)
+	allDiagnostics = append(allDiagnostics, parser.diagnostics...)
+	diagnostics.Sort(allDiagnostics)
+
+	return allDiagnostics
 }
 
 func (parser *Parser) parseStatement() ast.Statement {
 	switch parser.currentToken.Type {
-	case token.LET:
+	case token.LET, token.CONST:
 		return parser.parseLetStatement()
 
 	case token.RETURN:
 		return parser.parseReturnStatement()
 
+	case token.THROW:
+		return parser.parseThrowStatement()
+
+	case token.WHILE:
+		return parser.parseWhileStatement()
+
+	case token.FOR:
+		return parser.parseForStatement()
+
+	case token.BREAK, token.CONTINUE:
+		return parser.parseLoopControlStatement()
+
 	case token.FUNCTION:
-		return parser.parseFunctionStatement()
+		if parser.expectPeekToken(token.IDENT) {
+			return parser.parseFunctionStatement()
+		}
+
+		return parser.parseExpressionStatement()
 
 	default:
 		return parser.parseExpressionStatement()
@@ -112,6 +226,10 @@ func (parser *Parser) parseLetStatement() *ast.LetStatement {
 	parser.readNextToken()
 	statement.Value = parser.parseExpression(LOWEST)
 
+	if function, ok := statement.Value.(*ast.FunctionLiteral); ok {
+		function.Name = statement.Name.Value
+	}
+
 	if parser.expectPeekToken(token.SEMICOLON) {
 		parser.readNextToken()
 	}
@@ -124,12 +242,171 @@ func (parser *Parser) parseReturnStatement() *ast.ReturnStatement {
 
 	parser.readNextToken()
 	statement.Value = parser.parseExpression(LOWEST)
-	parser.readNextTokenIfPeekExpect(token.SEMICOLON)
+
+	if parser.expectPeekToken(token.SEMICOLON) {
+		parser.readNextToken()
+	}
+
+	return statement
+}
+
+func (parser *Parser) parseThrowStatement() *ast.ThrowStatement {
+	statement := &ast.ThrowStatement{BaseNode: ast.BaseNode{Token: parser.currentToken}}
+
+	parser.readNextToken()
+	statement.Value = parser.parseExpression(LOWEST)
+
+	if parser.expectPeekToken(token.SEMICOLON) {
+		parser.readNextToken()
+	}
+
+	return statement
+}
+
+func (parser *Parser) parseWhileStatement() *ast.WhileStatement {
+	statement := &ast.WhileStatement{BaseNode: ast.BaseNode{Token: parser.currentToken}}
+
+	if !parser.readNextTokenIfPeekExpect(token.LPAREN) {
+		return nil
+	}
+
+	statement.Condition = parser.parseGroupedExpression()
+
+	if !parser.readNextTokenIfPeekExpect(token.LBRACE) {
+		return nil
+	}
+
+	statement.Body = parser.parseLoopBody()
+
+	return statement
+}
+
+func (parser *Parser) parseForStatement() ast.Statement {
+	forToken := parser.currentToken
+
+	if !parser.readNextTokenIfPeekExpect(token.LPAREN) {
+		return nil
+	}
+
+	parser.readNextToken()
+
+	if parser.expectCurrentToken(token.IDENT) && parser.expectPeekToken(token.IN) {
+		return parser.parseForInStatement(forToken)
+	}
+
+	statement := &ast.ForStatement{BaseNode: ast.BaseNode{Token: forToken}}
+
+	if !parser.expectCurrentToken(token.SEMICOLON) {
+		statement.Init = parser.parseStatement()
+
+		if !parser.expectCurrentToken(token.SEMICOLON) && !parser.readNextTokenIfPeekExpect(token.SEMICOLON) {
+			return nil
+		}
+	}
+
+	parser.readNextToken()
+
+	if !parser.expectCurrentToken(token.SEMICOLON) {
+		statement.Condition = parser.parseExpression(LOWEST)
+
+		if !parser.readNextTokenIfPeekExpect(token.SEMICOLON) {
+			return nil
+		}
+	}
+
+	parser.readNextToken()
+
+	if !parser.expectCurrentToken(token.RPAREN) {
+		statement.Update = parser.parseStatement()
+
+		if !parser.readNextTokenIfPeekExpect(token.RPAREN) {
+			return nil
+		}
+	}
+
+	if !parser.readNextTokenIfPeekExpect(token.LBRACE) {
+		return nil
+	}
+
+	statement.Body = parser.parseLoopBody()
+
+	return statement
+}
+
+func (parser *Parser) parseForInStatement(forToken token.Token) ast.Statement {
+	statement := &ast.ForInStatement{
+		BaseNode: ast.BaseNode{Token: forToken},
+		Variable: &ast.Identifier{
+			BaseNode: ast.BaseNode{Token: parser.currentToken},
+			Value:    parser.currentToken.Literal,
+		},
+	}
+
+	parser.readNextToken()
+	parser.readNextToken()
+	statement.Iterable = parser.parseExpression(LOWEST)
+
+	if !parser.readNextTokenIfPeekExpect(token.RPAREN) {
+		return nil
+	}
+
+	if !parser.readNextTokenIfPeekExpect(token.LBRACE) {
+		return nil
+	}
+
+	statement.Body = parser.parseLoopBody()
+
+	return statement
+}
+
+// parseLoopBody parses the block ending a loop statement, along with the
+// optional semicolon after it.
+func (parser *Parser) parseLoopBody() *ast.BlockStatement {
+	enclosingValueDepth, enclosingLoopControls := parser.valueDepth, len(parser.loopControls)
+	parser.loopDepth++
+	parser.valueDepth = 0
+	body := parser.parseBlockStatement()
+	parser.loopDepth--
+	parser.valueDepth, parser.loopControls = enclosingValueDepth, parser.loopControls[:enclosingLoopControls]
+
+	if parser.expectPeekToken(token.SEMICOLON) {
+		parser.readNextToken()
+	}
+
+	return body
+}
+
+func (parser *Parser) parseLoopControlStatement() ast.Statement {
+	var statement ast.Statement
+
+	if parser.expectCurrentToken(token.BREAK) {
+		statement = &ast.BreakStatement{BaseNode: ast.BaseNode{Token: parser.currentToken}}
+	} else {
+		statement = &ast.ContinueStatement{BaseNode: ast.BaseNode{Token: parser.currentToken}}
+	}
+
+	if parser.loopDepth == 0 {
+		parser.writeError(diagnostics.OUTSIDE_LOOP, parser.currentToken, fmt.Sprintf("%s outside of a loop", parser.currentToken.Literal))
+	} else if parser.valueDepth > 0 {
+		parser.writeLoopControlError(parser.currentToken)
+	} else {
+		parser.loopControls = append(parser.loopControls, parser.currentToken)
+	}
+
+	if parser.expectPeekToken(token.SEMICOLON) {
+		parser.readNextToken()
+	}
 
 	return statement
 }
 
+func (parser *Parser) writeLoopControlError(loopControl token.Token) {
+	parser.writeError(diagnostics.CONTROL_IN_VALUE, loopControl, fmt.Sprintf("%s inside an expression whose value is used", loopControl.Literal))
+}
+
 func (parser *Parser) parseExpressionStatement() *ast.ExpressionStatement {
+	parser.statementExpression = true
+
 	statement := &ast.ExpressionStatement{
 		BaseNode:   ast.BaseNode{Token: parser.currentToken},
 		Expression: parser.parseExpression(LOWEST),
@@ -143,13 +420,21 @@ func (parser *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 }
 
 func (parser *Parser) parseExpression(precendance int) ast.Expression {
+	isStatement := parser.statementExpression
+	parser.statementExpression = false
+	loopControls := len(parser.loopControls)
 	prefixFn := parser.prefixParseFns[parser.currentToken.Type]
 
 	if prefixFn == nil {
-		parser.writeError(fmt.Sprintf("no prefix parse function for %s found", parser.currentToken.Literal))
+		parser.writeError(diagnostics.MISSING_EXPRESSION, parser.currentToken, fmt.Sprintf("no prefix parse function for %s found", parser.currentToken.Literal))
 		return nil
 	}
 
+	if !isStatement {
+		parser.valueDepth++
+		defer func() { parser.valueDepth-- }()
+	}
+
 	leftExp := prefixFn()
 
 	for !parser.expectPeekToken(token.SEMICOLON) && precendance < parser.peekTokenPrecedence() {
@@ -159,6 +444,16 @@ func (parser *Parser) parseExpression(precendance int) ast.Expression {
 			return leftExp
 		}
 
+		// the operator uses the value of the statement expression after all
+		if isStatement {
+			for _, loopControl := range parser.loopControls[loopControls:] {
+				parser.writeLoopControlError(loopControl)
+			}
+
+			parser.loopControls = parser.loopControls[:loopControls]
+			isStatement = false
+		}
+
 		parser.readNextToken()
 		leftExp = infixFn(leftExp)
 	}
@@ -166,6 +461,13 @@ func (parser *Parser) parseExpression(precendance int) ast.Expression {
 	return leftExp
 }
 
+func (parser *Parser) parseIllegal() ast.Expression {
+	// the lexer has already reported the illegal token
+	parser.panicking = true
+
+	return nil
+}
+
 func (parser *Parser) parseIdentifier() ast.Expression {
 	expression := &ast.Identifier{
 		BaseNode: ast.BaseNode{Token: parser.currentToken},
@@ -181,7 +483,42 @@ func (parser *Parser) parseIntegerLiteral() ast.Expression {
 	value, err := strconv.ParseInt(parser.currentToken.Literal, 0, 64)
 
 	if err != nil {
-		parser.writeError(fmt.Sprintf("could not parse %q as integer", parser.currentToken.Literal))
+		diagnostic := parser.writeError(diagnostics.INVALID_LITERAL, parser.currentToken, fmt.Sprintf("could not parse %q as integer", parser.currentToken.Literal))
+
+		if diagnostic != nil && errors.Is(err, strconv.ErrRange) {
+			diagnostic.Notes = append(diagnostic.Notes, "integers are 64-bit; add the n suffix for a big integer")
+		}
+
+		return nil
+	}
+
+	literal.Value = value
+
+	return literal
+}
+
+func (parser *Parser) parseBigIntLiteral() ast.Expression {
+	literal := &ast.BigIntLiteral{BaseNode: ast.BaseNode{Token: parser.currentToken}}
+
+	value, ok := new(big.Int).SetString(strings.TrimSuffix(parser.currentToken.Literal, "n"), 0)
+
+	if !ok {
+		parser.writeError(diagnostics.INVALID_LITERAL, parser.currentToken, fmt.Sprintf("could not parse %q as big integer", parser.currentToken.Literal))
+		return nil
+	}
+
+	literal.Value = value
+
+	return literal
+}
+
+func (parser *Parser) parseFloatLiteral() ast.Expression {
+	literal := &ast.FloatLiteral{BaseNode: ast.BaseNode{Token: parser.currentToken}}
+
+	value, err := strconv.ParseFloat(parser.currentToken.Literal, 64)
+
+	if err != nil {
+		parser.writeError(diagnostics.INVALID_LITERAL, parser.currentToken, fmt.Sprintf("could not parse %q as float", parser.currentToken.Literal))
 		return nil
 	}
 
@@ -249,6 +586,62 @@ func (parser *Parser) parseIfExpression() ast.Expression {
 	return expression
 }
 
+func (parser *Parser) parseTryExpression() ast.Expression {
+	expression := &ast.TryExpression{
+		BaseNode: ast.BaseNode{Token: parser.currentToken},
+	}
+
+	if !parser.readNextTokenIfPeekExpect(token.LBRACE) {
+		return nil
+	}
+
+	expression.Block = parser.parseBlockStatement()
+
+	if parser.expectPeekToken(token.CATCH) {
+		parser.readNextToken()
+
+		if parser.expectPeekToken(token.LPAREN) {
+			parser.readNextToken()
+
+			if !parser.readNextTokenIfPeekExpect(token.IDENT) {
+				return nil
+			}
+
+			expression.Parameter = &ast.Identifier{
+				BaseNode: ast.BaseNode{Token: parser.currentToken},
+				Value:    parser.currentToken.Literal,
+			}
+
+			if !parser.readNextTokenIfPeekExpect(token.RPAREN) {
+				return nil
+			}
+		}
+
+		if !parser.readNextTokenIfPeekExpect(token.LBRACE) {
+			return nil
+		}
+
+		expression.Catch = parser.parseBlockStatement()
+	}
+
+	if parser.expectPeekToken(token.FINALLY) {
+		parser.readNextToken()
+
+		if !parser.readNextTokenIfPeekExpect(token.LBRACE) {
+			return nil
+		}
+
+		expression.Finally = parser.parseBlockStatement()
+	}
+
+	if expression.Catch == nil && expression.Finally == nil {
+		parser.writeError(diagnostics.UNEXPECTED_TOKEN, parser.peekToken, fmt.Sprintf("expected catch or finally after try block, got %s instead", parser.peekToken.Type))
+		return nil
+	}
+
+	return expression
+}
+
 func (parser *Parser) parseBlockStatement() *ast.BlockStatement {
 	statement := &ast.BlockStatement{
 		BaseNode: ast.BaseNode{Token: parser.currentToken},
@@ -256,13 +649,17 @@ func (parser *Parser) parseBlockStatement() *ast.BlockStatement {
 
 	parser.readNextToken()
 
-	for !(parser.expectCurrentToken(token.RBRACE) || parser.expectCurrentToken(token.EOF)) {
+	for !(parser.expectCurrentToken(token.RBRACE) || parser.expectCurrentToken(token.EOF)) && !parser.hasTooManyErrors() {
 		subStatement := parser.parseStatement()
 
 		if subStatement != nil {
 			statement.Statements = append(statement.Statements, subStatement)
 		}
 
+		if parser.panicking && parser.synchronize() {
+			break
+		}
+
 		parser.readNextToken()
 	}
 
@@ -295,6 +692,34 @@ func (parser *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	return expression
 }
 
+// parseAssignExpression parses the value with a lower precedence than its
+// own, so that chained assignments group to the right.
+func (parser *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	expression := &ast.AssignExpression{
+		BaseNode: ast.BaseNode{Token: parser.currentToken},
+		Target:   target,
+		Operator: parser.currentToken.Literal,
+	}
+
+	switch target.(type) {
+	case nil:
+		return nil
+	case *ast.Identifier, *ast.IndexExpression:
+	default:
+		parser.writeError(diagnostics.INVALID_ASSIGNMENT, parser.currentToken, fmt.Sprintf("can not assign to %s", target.ToString()))
+		return nil
+	}
+
+	parser.readNextToken()
+	expression.Value = parser.parseExpression(LOWEST)
+
+	if expression.Value == nil {
+		return nil
+	}
+
+	return expression
+}
+
 func (parser *Parser) parseFunctionStatement() *ast.LetStatement {
 	literal := &ast.LetStatement{
 		BaseNode: ast.BaseNode{
@@ -314,6 +739,14 @@ func (parser *Parser) parseFunctionStatement() *ast.LetStatement {
 
 	literal.Value = parser.parseFunctionLiteral()
 
+	if function, ok := literal.Value.(*ast.FunctionLiteral); ok && literal.Name != nil {
+		function.Name = literal.Name.Value
+	}
+
+	if parser.expectPeekToken(token.SEMICOLON) {
+		parser.readNextToken()
+	}
+
 	return literal
 }
 
@@ -334,7 +767,11 @@ func (parser *Parser) parseFunctionLiteral() ast.Expression {
 		return nil
 	}
 
+	enclosingLoopDepth, enclosingValueDepth := parser.loopDepth, parser.valueDepth
+	parser.loopDepth, parser.valueDepth = 0, 0
 	literal.Body = parser.parseBlockStatement()
+	parser.loopDepth, parser.valueDepth = enclosingLoopDepth, enclosingValueDepth
+	ast.MarkTailCalls(literal.Body)
 
 	return literal
 }
@@ -383,33 +820,96 @@ func (parser *Parser) parseCallExpression(function ast.Expression) ast.Expressio
 		Function: function,
 	}
 
-	expression.Arguments = parser.parseArguments()
+	expression.Arguments = parser.parseExpressionList(token.RPAREN)
 
 	return expression
 }
 
-func (parser *Parser) parseArguments() []ast.Expression {
-	arguments := []ast.Expression{}
+func (parser *Parser) parseArrayLiteral() ast.Expression {
+	literal := &ast.ArrayLiteral{
+		BaseNode: ast.BaseNode{
+			Token: parser.currentToken,
+		},
+	}
+
+	literal.Elements = parser.parseExpressionList(token.RBRACKET)
+
+	return literal
+}
+
+func (parser *Parser) parseHashLiteral() ast.Expression {
+	literal := &ast.HashLiteral{
+		BaseNode: ast.BaseNode{
+			Token: parser.currentToken,
+		},
+		Pairs: []ast.HashPair{},
+	}
+
+	for !parser.expectPeekToken(token.RBRACE) {
+		parser.readNextToken()
+		key := parser.parseExpression(LOWEST)
+
+		if !parser.readNextTokenIfPeekExpect(token.COLON) {
+			return nil
+		}
+
+		parser.readNextToken()
+		value := parser.parseExpression(LOWEST)
+
+		literal.Pairs = append(literal.Pairs, ast.HashPair{Key: key, Value: value})
+
+		if !parser.expectPeekToken(token.RBRACE) && !parser.readNextTokenIfPeekExpect(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !parser.readNextTokenIfPeekExpect(token.RBRACE) {
+		return nil
+	}
+
+	return literal
+}
+
+func (parser *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	expression := &ast.IndexExpression{
+		BaseNode: ast.BaseNode{
+			Token: parser.currentToken,
+		},
+		Left: left,
+	}
 
 	parser.readNextToken()
+	expression.Index = parser.parseExpression(LOWEST)
 
-	if parser.expectCurrentToken(token.RPAREN) {
-		return arguments
+	if !parser.readNextTokenIfPeekExpect(token.RBRACKET) {
+		return nil
 	}
 
-	arguments = append(arguments, parser.parseExpression(LOWEST))
+	return expression
+}
+
+func (parser *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
+	list := []ast.Expression{}
+
+	parser.readNextToken()
+
+	if parser.expectCurrentToken(end) {
+		return list
+	}
+
+	list = append(list, parser.parseExpression(LOWEST))
 
 	for parser.expectPeekToken(token.COMMA) {
 		parser.readNextToken()
 		parser.readNextToken()
-		arguments = append(arguments, parser.parseExpression(LOWEST))
+		list = append(list, parser.parseExpression(LOWEST))
 	}
 
-	if !parser.readNextTokenIfPeekExpect(token.RPAREN) {
+	if !parser.readNextTokenIfPeekExpect(end) {
 		return nil
 	}
 
-	return arguments
+	return list
 }
 
 func (parser *Parser) expectCurrentToken(tokenType token.TokenType) bool {
@@ -438,7 +938,20 @@ func (parser *Parser) peekTokenPrecedence() int {
 
 func (parser *Parser) readNextToken() {
 	parser.currentToken = parser.peekToken
-	parser.peekToken = parser.lexer.ReadNextToken()
+	parser.peekToken = parser.readSignificantToken()
+}
+
+// readSignificantToken reads the next token that is not a comment, setting
+// the comments it passes aside.
+func (parser *Parser) readSignificantToken() token.Token {
+	nextToken := parser.lexer.ReadNextToken()
+
+	for nextToken.Type == token.COMMENT {
+		parser.comments = append(parser.comments, nextToken)
+		nextToken = parser.lexer.ReadNextToken()
+	}
+
+	return nextToken
 }
 
 func (parser *Parser) readNextTokenIfPeekExpect(tokenType token.TokenType) bool {
@@ -448,13 +961,88 @@ func (parser *Parser) readNextTokenIfPeekExpect(tokenType token.TokenType) bool
 		return true
 	}
 
-	parser.writeError(fmt.Sprintf("expected next token to be %s, got %s instead", string(tokenType), string(parser.peekToken.Type)))
+	diagnostic := parser.writeError(diagnostics.UNEXPECTED_TOKEN, parser.peekToken, fmt.Sprintf("expected next token to be %s, got %s instead", string(tokenType), string(parser.peekToken.Type)))
+
+	if literal, ok := insertableTokens[tokenType]; ok && diagnostic != nil {
+		diagnostic.Fix = &diagnostics.Fix{
+			Message:     fmt.Sprintf("insert `%s` before `%s`", literal, parser.peekToken.Literal),
+			Span:        token.Span{Start: parser.peekToken.Span.Start, End: parser.peekToken.Span.Start},
+			Replacement: literal,
+		}
+	}
 
 	return false
 }
 
-func (parser *Parser) writeError(errorMessage string) {
-	parser.errors = append(parser.errors, errorMessage)
+// writeError returns the recorded diagnostic, or nil when the error was
+// silenced as a cascade of a previous one.
+func (parser *Parser) writeError(code string, errorToken token.Token, errorMessage string) *diagnostics.Diagnostic {
+	if errorToken.Type == token.ILLEGAL {
+		// the lexer has already reported the illegal token
+		parser.panicking = true
+		return nil
+	}
+
+	if parser.panicking || parser.hasTooManyErrors() {
+		return nil
+	}
+
+	parser.panicking = true
+	diagnostic := diagnostics.New(code, errorToken.Span, errorMessage)
+
+	for _, existingDiagnostic := range parser.diagnostics {
+		if existingDiagnostic.ToString() == diagnostic.ToString() {
+			return nil
+		}
+	}
+
+	parser.diagnostics = append(parser.diagnostics, diagnostic)
+	index := len(parser.diagnostics) - 1
+
+	if len(parser.diagnostics) == MAX_ERRORS {
+		parser.diagnostics = append(parser.diagnostics, diagnostics.New(diagnostics.TOO_MANY_ERRORS, errorToken.Span, "too many errors"))
+	}
+
+	return &parser.diagnostics[index]
+}
+
+func (parser *Parser) hasTooManyErrors() bool {
+	return len(parser.diagnostics) > MAX_ERRORS
+}
+
+// synchronize skips the rest of a broken statement, stopping on its
+// semicolon or right before the next statement. It reports whether it
+// stopped on a closing brace that ends the enclosing block.
+func (parser *Parser) synchronize() bool {
+	parser.panicking = false
+	depth := 0
+
+	for !parser.expectCurrentToken(token.EOF) {
+		switch parser.currentToken.Type {
+		case token.LBRACE:
+			depth++
+
+		case token.RBRACE:
+			if depth == 0 {
+				return true
+			}
+
+			depth--
+
+		case token.SEMICOLON:
+			if depth == 0 {
+				return false
+			}
+		}
+
+		if depth == 0 && (parser.expectPeekToken(token.RBRACE) || parser.expectPeekToken(token.EOF) || statementKeywords[parser.peekToken.Type]) {
+			return false
+		}
+
+		parser.readNextToken()
+	}
+
+	return false
 }
 
 func (parser *Parser) registerPrefixParseFn(tokenType token.TokenType, fn prefixParseFn) {
@@ -466,11 +1054,15 @@ func (parser *Parser) registerInfixParseFn(tokenType token.TokenType, fn infixPa
 }
 
 func (parser *Parser) initialize() {
-	parser.currentToken = parser.lexer.ReadNextToken()
-	parser.peekToken = parser.lexer.ReadNextToken()
+	parser.currentToken = parser.readSignificantToken()
+	parser.peekToken = parser.readSignificantToken()
 
+	parser.registerPrefixParseFn(token.ILLEGAL, parser.parseIllegal)
 	parser.registerPrefixParseFn(token.IDENT, parser.parseIdentifier)
 	parser.registerPrefixParseFn(token.INT, parser.parseIntegerLiteral)
+	parser.registerPrefixParseFn(token.TRY, parser.parseTryExpression)
+	parser.registerPrefixParseFn(token.FLOAT, parser.parseFloatLiteral)
+	parser.registerPrefixParseFn(token.BIGINT, parser.parseBigIntLiteral)
 	parser.registerPrefixParseFn(token.FALSE, parser.parseBoolean)
 	parser.registerPrefixParseFn(token.TRUE, parser.parseBoolean)
 	parser.registerPrefixParseFn(token.STRING, parser.parseString)
@@ -479,8 +1071,17 @@ func (parser *Parser) initialize() {
 	parser.registerPrefixParseFn(token.FUNCTION, parser.parseFunctionLiteral)
 	parser.registerPrefixParseFn(token.BANG, parser.parsePrefixExpression)
 	parser.registerPrefixParseFn(token.MINUS, parser.parsePrefixExpression)
+	parser.registerPrefixParseFn(token.LBRACKET, parser.parseArrayLiteral)
+	parser.registerPrefixParseFn(token.LBRACE, parser.parseHashLiteral)
 
 	parser.registerInfixParseFn(token.LPAREN, parser.parseCallExpression)
+	parser.registerInfixParseFn(token.LBRACKET, parser.parseIndexExpression)
+	parser.registerInfixParseFn(token.ASSIGN, parser.parseAssignExpression)
+	parser.registerInfixParseFn(token.PLUS_ASSIGN, parser.parseAssignExpression)
+	parser.registerInfixParseFn(token.MINUS_ASSIGN, parser.parseAssignExpression)
+	parser.registerInfixParseFn(token.ASTERISK_ASSIGN, parser.parseAssignExpression)
+	parser.registerInfixParseFn(token.SLASH_ASSIGN, parser.parseAssignExpression)
+	parser.registerInfixParseFn(token.PERCENT_ASSIGN, parser.parseAssignExpression)
 	parser.registerInfixParseFn(token.PLUS, parser.parseInfixExpression)
 	parser.registerInfixParseFn(token.MINUS, parser.parseInfixExpression)
 	parser.registerInfixParseFn(token.SLASH, parser.parseInfixExpression)
@@ -489,4 +1090,14 @@ func (parser *Parser) initialize() {
 	parser.registerInfixParseFn(token.NOT_EQ, parser.parseInfixExpression)
 	parser.registerInfixParseFn(token.LT, parser.parseInfixExpression)
 	parser.registerInfixParseFn(token.GT, parser.parseInfixExpression)
+	parser.registerInfixParseFn(token.PERCENT, parser.parseInfixExpression)
+	parser.registerInfixParseFn(token.LT_EQ, parser.parseInfixExpression)
+	parser.registerInfixParseFn(token.GT_EQ, parser.parseInfixExpression)
+	parser.registerInfixParseFn(token.AND, parser.parseInfixExpression)
+	parser.registerInfixParseFn(token.OR, parser.parseInfixExpression)
+	parser.registerInfixParseFn(token.AMPERSAND, parser.parseInfixExpression)
+	parser.registerInfixParseFn(token.PIPE, parser.parseInfixExpression)
+	parser.registerInfixParseFn(token.CARET, parser.parseInfixExpression)
+	parser.registerInfixParseFn(token.SHIFT_LEFT, parser.parseInfixExpression)
+	parser.registerInfixParseFn(token.SHIFT_RIGHT, parser.parseInfixExpression)
 }
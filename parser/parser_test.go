@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"compiler/diagnostics"
+	"compiler/lexer"
+	"strings"
+	"testing"
+)
+
+func TestRetainedCommentsAreSkipped(t *testing.T) {
+	input := `// leading
+let x = /* inline */ 1; // trailing
+/* before */ x + /* between */ 2 // last`
+
+	withComments := lexer.New(input)
+	withComments.RetainComments()
+	parser := New(withComments)
+	program := parser.ParseProgram()
+
+	if errors := parser.GetParsingErrors(); len(errors) > 0 {
+		t.Fatalf("parsing errors %v", errors)
+	}
+
+	expected := New(lexer.New(input)).ParseProgram()
+
+	if program.ToString() != expected.ToString() {
+		t.Errorf("got %q, want %q", program.ToString(), expected.ToString())
+	}
+
+	if comments := parser.Comments(); len(comments) != 6 {
+		t.Errorf("got %d comments, want 6: %v", len(comments), comments)
+	}
+}
+
+func TestLoopControlInsideValues(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"while (true) { if (x) { break } else { 1 } }", nil},
+		{"while (true) { try { continue } catch (e) { 1 } }", nil},
+		{"while (true) { let y = if (x) { while (true) { break }; 1 } }", nil},
+		{"while (true) { let y = 1 + if (x) { break } else { 2 } }", []string{"1:37"}},
+		{"while (true) { if (x) { break } else { 2 } + 1 }", []string{"1:25"}},
+		{"while (true) { if (x) { if (y) { continue } } * 2 }", []string{"1:34"}},
+		{"while (true) { f(if (x) { break }) }", []string{"1:27"}},
+	}
+
+	for _, test := range tests {
+		parser := New(lexer.New(test.input))
+		parser.ParseProgram()
+
+		var positions []string
+
+		for _, diagnostic := range parser.GetDiagnostics() {
+			positions = append(positions, diagnostic.Span.Start.ToString())
+		}
+
+		if strings.Join(positions, " ") != strings.Join(test.expected, " ") {
+			t.Errorf("%s: got errors %v, want them at %v", test.input, parser.GetParsingErrors(), test.expected)
+		}
+	}
+}
+
+func TestLeadingZerosAreRejected(t *testing.T) {
+	for _, input := range []string{"let x = 010;", "let x = 09;"} {
+		parser := New(lexer.New(input))
+		parser.ParseProgram()
+		parsingDiagnostics := parser.GetDiagnostics()
+
+		if len(parsingDiagnostics) != 1 || parsingDiagnostics[0].Code != diagnostics.INVALID_NUMBER || parsingDiagnostics[0].Span.Start.ToString() != "1:9" {
+			t.Errorf("%s: got errors %v, want an invalid number at 1:9", input, parser.GetParsingErrors())
+		}
+	}
+}
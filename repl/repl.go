@@ -34,6 +34,12 @@ func Start(in io.Reader, out io.Writer) {
 			continue
 		}
 
-		fmt.Print(evaluator.Eval(program, environment), "\n")
+		if result := evaluator.Eval(program, environment); result != nil {
+			fmt.Print(result.Inspect(), "\n")
+
+			if errorObj, ok := result.(*object.Error); ok {
+				fmt.Print(errorObj.StackTrace())
+			}
+		}
 	}
 }
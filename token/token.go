@@ -1,19 +1,37 @@
 package token
 
+import "fmt"
+
 type TokenType string
 
 type Token struct {
 	Type    TokenType
 	Literal string
+	Span    Span
+}
+
+type Position struct {
+	File   string
+	Offset int
+	Line   int
+	Column int
+}
+
+type Span struct {
+	Start Position
+	End   Position
 }
 
 const (
 	ILLEGAL = "ILLEGAL"
 	EOF     = "EOF"
+	COMMENT = "COMMENT"
 
 	// identifications and literals
 	IDENT  = "IDENT"
 	INT    = "INT"
+	FLOAT  = "FLOAT"
+	BIGINT = "BIGINT"
 	STRING = "STRING"
 
 	// math operators
@@ -23,42 +41,87 @@ const (
 	BANG     = "!"
 	ASTERISK = "*"
 	SLASH    = "/"
+	PERCENT  = "%"
 	LT       = "<"
 	GT       = ">"
+	LT_EQ    = "<="
+	GT_EQ    = ">="
 	EQ       = "=="
 	NOT_EQ   = "!="
 
+	// assignment operators
+	PLUS_ASSIGN     = "+="
+	MINUS_ASSIGN    = "-="
+	ASTERISK_ASSIGN = "*="
+	SLASH_ASSIGN    = "/="
+	PERCENT_ASSIGN  = "%="
+
+	// logical operators
+	AND = "&&"
+	OR  = "||"
+
+	// bitwise operators
+	AMPERSAND   = "&"
+	PIPE        = "|"
+	CARET       = "^"
+	SHIFT_LEFT  = "<<"
+	SHIFT_RIGHT = ">>"
+
 	// delimiters
 	COMMA     = "COMMA"
 	SEMICOLON = ";"
+	COLON     = ":"
 
 	LPAREN = "("
 	RPAREN = ")"
 	LBRACE = "{"
 	RBRACE = "}"
 
+	LBRACKET = "["
+	RBRACKET = "]"
+
 	// keywords
 	FUNCTION = "FUNCTION"
 	LET      = "LET"
+	CONST    = "CONST"
 	TRUE     = "TRUE"
 	FALSE    = "FALSE"
 	IF       = "IF"
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
+	WHILE    = "WHILE"
+	FOR      = "FOR"
+	IN       = "IN"
+	BREAK    = "BREAK"
+	CONTINUE = "CONTINUE"
+	TRY      = "TRY"
+	CATCH    = "CATCH"
+	FINALLY  = "FINALLY"
+	THROW    = "THROW"
 )
 
 var keywords = map[string]TokenType{
-	"fn":     FUNCTION,
-	"let":    LET,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
+	"fn":       FUNCTION,
+	"let":      LET,
+	"const":    CONST,
+	"true":     TRUE,
+	"false":    FALSE,
+	"if":       IF,
+	"else":     ELSE,
+	"return":   RETURN,
+	"while":    WHILE,
+	"for":      FOR,
+	"in":       IN,
+	"break":    BREAK,
+	"continue": CONTINUE,
+	"try":      TRY,
+	"catch":    CATCH,
+	"finally":  FINALLY,
+	"throw":    THROW,
 }
 
 func New(tokenType TokenType, value string) Token {
-	return Token{tokenType, value}
+	return Token{Type: tokenType, Literal: value}
 }
 
 func DefineTokenType(value string) TokenType {
@@ -68,3 +131,15 @@ func DefineTokenType(value string) TokenType {
 	return IDENT
 
 }
+
+func (position Position) IsValid() bool {
+	return position.Line > 0
+}
+
+func (position Position) ToString() string {
+	if position.File == "" {
+		return fmt.Sprintf("%d:%d", position.Line, position.Column)
+	}
+
+	return fmt.Sprintf("%s:%d:%d", position.File, position.Line, position.Column)
+}
@@ -0,0 +1,23 @@
+package vm
+
+import "compiler/object"
+
+const CELL_OBJ = "CELL"
+
+// cell boxes a local variable once a closure captures it, so assignments
+// made through the closure and through the declaring frame stay shared.
+// Cells live only in stack slots and free variables; every read unwraps them.
+type cell struct {
+	value object.Object
+}
+
+func (cellObj *cell) GetObjectType() object.ObjectType { return CELL_OBJ }
+func (cellObj *cell) Inspect() string                  { return cellObj.value.Inspect() }
+
+func unwrapCell(value object.Object) object.Object {
+	if cellObj, ok := value.(*cell); ok {
+		return cellObj.value
+	}
+
+	return value
+}
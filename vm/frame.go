@@ -0,0 +1,20 @@
+package vm
+
+import (
+	"compiler/compiler"
+	"compiler/object"
+)
+
+type Frame struct {
+	closure     *object.Closure
+	ip          int
+	basePointer int
+}
+
+func NewFrame(closure *object.Closure, basePointer int) *Frame {
+	return &Frame{closure: closure, basePointer: basePointer}
+}
+
+func (frame *Frame) Instructions() compiler.Instructions {
+	return frame.closure.Fn.Instructions
+}
@@ -0,0 +1,461 @@
+package vm
+
+import (
+	"compiler/compiler"
+	"compiler/evaluator"
+	"compiler/object"
+	"fmt"
+)
+
+// STACK_SIZE is the initial size of the stack, which grows with the calls
+// in progress; their count is bounded by Settings.CallDepthLimit.
+const (
+	STACK_SIZE   = 2048
+	GLOBALS_SIZE = 65536
+)
+
+// handler is an active try: an error raised while it is active resumes the
+// program at catchPosition, in the frame and with the stack it started with.
+type handler struct {
+	framesIndex   int
+	stackPointer  int
+	catchPosition int
+}
+
+type VM struct {
+	constants []object.Object
+	globals   []object.Object
+
+	stack        []object.Object
+	stackPointer int
+
+	frames      []*Frame
+	framesIndex int
+
+	handlers []handler
+
+	settings *object.Settings
+	result   object.Object
+}
+
+func New(bytecode *compiler.Bytecode) *VM {
+	return NewWithGlobals(bytecode, make([]object.Object, GLOBALS_SIZE))
+}
+
+func NewWithGlobals(bytecode *compiler.Bytecode, globals []object.Object) *VM {
+	return NewWithSettings(bytecode, globals, &object.Settings{})
+}
+
+func NewWithSettings(bytecode *compiler.Bytecode, globals []object.Object, settings *object.Settings) *VM {
+	mainFunction := &object.CompiledFunction{Instructions: bytecode.Instructions, Positions: bytecode.Positions}
+	frames := make([]*Frame, settings.CallDepthLimit()+1)
+	frames[0] = NewFrame(&object.Closure{Fn: mainFunction}, 0)
+
+	return &VM{
+		constants:   bytecode.Constants,
+		globals:     globals,
+		settings:    settings,
+		stack:       make([]object.Object, STACK_SIZE),
+		frames:      frames,
+		framesIndex: 1,
+	}
+}
+
+// Result returns the value of the program after Run, or the error object
+// that stopped it, exactly as evaluator.Eval would report them.
+func (vm *VM) Result() object.Object {
+	return vm.result
+}
+
+func (vm *VM) Run() error {
+	for vm.result == nil {
+		frame := vm.currentFrame()
+		instructions := frame.Instructions()
+
+		if frame.ip >= len(instructions) {
+			return fmt.Errorf("instruction pointer %d out of bounds", frame.ip)
+		}
+
+		position := frame.ip
+		opcode := compiler.Opcode(instructions[frame.ip])
+		frame.ip++
+
+		var err error
+
+		switch opcode {
+		case compiler.OpConstant:
+			err = vm.push(vm.constants[vm.readUint16()])
+
+		case compiler.OpPop:
+			vm.pop()
+
+		case compiler.OpTrue:
+			err = vm.push(evaluator.TRUE)
+
+		case compiler.OpFalse:
+			err = vm.push(evaluator.FALSE)
+
+		case compiler.OpNull:
+			err = vm.push(evaluator.NULL)
+
+		case compiler.OpArray:
+			countOfElements := int(vm.readUint16())
+			elements := make([]object.Object, countOfElements)
+			copy(elements, vm.stack[vm.stackPointer-countOfElements:vm.stackPointer])
+			vm.stackPointer = vm.stackPointer - countOfElements
+			err = vm.push(&object.Array{Elements: elements})
+
+		case compiler.OpHash:
+			countOfElements := int(vm.readUint16())
+			hash := vm.buildHash(vm.stack[vm.stackPointer-countOfElements : vm.stackPointer])
+			vm.stackPointer = vm.stackPointer - countOfElements
+			err = vm.pushResult(hash)
+
+		case compiler.OpIndex:
+			index := vm.pop()
+			left := vm.pop()
+			err = vm.pushResult(evaluator.EvalIndexOperator(left, index))
+
+		case compiler.OpSetIndex:
+			var operator string
+
+			if opcode := compiler.Opcode(vm.readUint8()); opcode != 0 {
+				operator, _ = compiler.InfixOperator(opcode)
+			}
+
+			value := vm.pop()
+			index := vm.pop()
+			left := vm.pop()
+			err = vm.pushResult(evaluator.EvalIndexAssignment(operator+"=", left, index, value, vm.settings))
+
+		case compiler.OpJump:
+			frame.ip = int(vm.readUint16())
+
+		case compiler.OpJumpNotTruthy:
+			position := int(vm.readUint16())
+
+			if !evaluator.IsTruthy(vm.pop()) {
+				frame.ip = position
+			}
+
+		case compiler.OpIterable:
+			array, errorObj := evaluator.ToIterable(vm.pop())
+
+			if errorObj != nil {
+				vm.result = errorObj
+			} else {
+				err = vm.push(array)
+			}
+
+		case compiler.OpIterNext:
+			position := int(vm.readUint16())
+			index := vm.pop().(*object.Integer).Value
+			elements := vm.pop().(*object.Array).Elements
+
+			if index < int64(len(elements)) {
+				err = vm.push(elements[index])
+			} else {
+				frame.ip = position
+			}
+
+		case compiler.OpSetGlobal:
+			vm.globals[vm.readUint16()] = vm.pop()
+
+		case compiler.OpAssignGlobal:
+			vm.assign(&vm.globals[vm.readUint16()], vm.pop())
+
+		case compiler.OpCaptureGlobal:
+			err = vm.push(vm.capture(&vm.globals[vm.readUint16()]))
+
+		case compiler.OpGetGlobal:
+			err = vm.push(unwrapCell(vm.globals[vm.readUint16()]))
+
+		case compiler.OpSetLocal:
+			vm.stack[frame.basePointer+int(vm.readUint8())] = vm.pop()
+
+		case compiler.OpAssignLocal:
+			vm.assign(&vm.stack[frame.basePointer+int(vm.readUint8())], vm.pop())
+
+		case compiler.OpCaptureLocal:
+			err = vm.push(vm.capture(&vm.stack[frame.basePointer+int(vm.readUint8())]))
+
+		case compiler.OpGetLocal:
+			err = vm.push(unwrapCell(vm.stack[frame.basePointer+int(vm.readUint8())]))
+
+		case compiler.OpGetFree:
+			err = vm.push(unwrapCell(frame.closure.Free[vm.readUint8()]))
+
+		case compiler.OpAssignFree:
+			vm.assign(&frame.closure.Free[vm.readUint8()], vm.pop())
+
+		case compiler.OpCaptureFree:
+			err = vm.push(vm.capture(&frame.closure.Free[vm.readUint8()]))
+
+		case compiler.OpGetBuiltin:
+			name := vm.constants[vm.readUint16()].(*object.String).Value
+
+			if builtin, exist := evaluator.LookupBuiltin(name); exist {
+				err = vm.push(builtin)
+			} else {
+				vm.result = evaluator.NewError(fmt.Sprintf("variable doesn`t exist %s", name))
+			}
+
+		case compiler.OpGetPredeclared:
+			value := vm.globals[vm.readUint16()]
+			name := vm.constants[vm.readUint16()].(*object.String).Value
+
+			if value == nil {
+				vm.result = evaluator.NewError(fmt.Sprintf("variable doesn`t exist %s", name))
+			} else {
+				err = vm.push(unwrapCell(value))
+			}
+
+		case compiler.OpClosure:
+			constantIndex := vm.readUint16()
+			freeCount := int(vm.readUint8())
+			err = vm.pushClosure(int(constantIndex), freeCount)
+
+		case compiler.OpCurrentClosure:
+			err = vm.push(frame.closure)
+
+		case compiler.OpCall:
+			err = vm.callFunction(int(vm.readUint8()))
+
+		case compiler.OpReturnValue:
+			returnValue := vm.pop()
+			returnedFrame := vm.popFrame()
+
+			if vm.framesIndex == 0 {
+				vm.result = returnValue
+				break
+			}
+
+			vm.stackPointer = returnedFrame.basePointer - 1
+			err = vm.push(returnValue)
+
+		case compiler.OpTry:
+			vm.handlers = append(vm.handlers, handler{
+				framesIndex:   vm.framesIndex,
+				stackPointer:  vm.stackPointer,
+				catchPosition: int(vm.readUint16()),
+			})
+
+		case compiler.OpEndTry:
+			vm.handlers = vm.handlers[:len(vm.handlers)-1]
+
+		case compiler.OpThrow:
+			vm.result = evaluator.RaiseValue(vm.pop())
+
+		default:
+			err = vm.executeOperator(opcode)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		errorObj, ok := vm.result.(*object.Error)
+
+		if ok && !errorObj.Span.Start.IsValid() {
+			errorObj.Span = frame.closure.Fn.Positions[position]
+		}
+
+		if ok && len(vm.handlers) > 0 {
+			err = vm.catch(errorObj)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// catch hands errorObj to the innermost handler, dropping the frames and
+// the stack above it.
+func (vm *VM) catch(errorObj *object.Error) error {
+	handler := vm.handlers[len(vm.handlers)-1]
+	vm.handlers = vm.handlers[:len(vm.handlers)-1]
+
+	vm.result = nil
+	vm.framesIndex = handler.framesIndex
+	vm.stackPointer = handler.stackPointer
+	vm.currentFrame().ip = handler.catchPosition
+
+	return vm.push(evaluator.CatchError(errorObj))
+}
+
+func (vm *VM) executeOperator(opcode compiler.Opcode) error {
+	if operator, exist := compiler.InfixOperator(opcode); exist {
+		secondArgument := vm.pop()
+		firstArgument := vm.pop()
+
+		return vm.pushResult(evaluator.EvalInfixOperator(operator, firstArgument, secondArgument, vm.settings))
+	}
+
+	if operator, exist := compiler.PrefixOperator(opcode); exist {
+		return vm.pushResult(evaluator.EvalPrefixOperator(operator, vm.pop(), vm.settings))
+	}
+
+	return fmt.Errorf("unknown opcode %d", opcode)
+}
+
+func (vm *VM) callFunction(argumentsCount int) error {
+	callee := vm.stack[vm.stackPointer-1-argumentsCount]
+
+	switch callee := callee.(type) {
+	case *object.Closure:
+		// the main frame is not a call
+		if maxCallDepth := len(vm.frames) - 1; vm.framesIndex > maxCallDepth {
+			vm.result = evaluator.NewRecursionError(maxCallDepth)
+			return nil
+		}
+
+		if argumentsCount != callee.Fn.NumParameters {
+			vm.result = evaluator.NewArityError(callee.Fn.Name, callee.Fn.NumParameters, argumentsCount)
+			return nil
+		}
+
+		frame := NewFrame(callee, vm.stackPointer-argumentsCount)
+
+		if err := vm.pushFrame(frame); err != nil {
+			return err
+		}
+
+		vm.reserve(callee.Fn.NumLocals)
+		vm.stackPointer = frame.basePointer + callee.Fn.NumLocals
+
+		return nil
+
+	case *object.Builtin:
+		arguments := vm.stack[vm.stackPointer-argumentsCount : vm.stackPointer]
+		result := callee.Fn(arguments...)
+		vm.stackPointer = vm.stackPointer - argumentsCount - 1
+
+		return vm.pushResult(result)
+
+	default:
+		vm.result = evaluator.NewError(fmt.Sprintf("not a function: %s", callee.GetObjectType()))
+		return nil
+	}
+}
+
+func (vm *VM) buildHash(elements []object.Object) object.Object {
+	hash := object.NewHash()
+
+	for index := 0; index < len(elements); index += 2 {
+		if errorObj := evaluator.InsertHashPair(hash, elements[index], elements[index+1]); errorObj != nil {
+			return errorObj
+		}
+	}
+
+	return hash
+}
+
+func (vm *VM) pushClosure(constantIndex int, freeCount int) error {
+	function, ok := vm.constants[constantIndex].(*object.CompiledFunction)
+
+	if !ok {
+		return fmt.Errorf("not a function: %+v", vm.constants[constantIndex])
+	}
+
+	free := make([]object.Object, freeCount)
+	copy(free, vm.stack[vm.stackPointer-freeCount:vm.stackPointer])
+	vm.stackPointer = vm.stackPointer - freeCount
+
+	return vm.push(&object.Closure{Fn: function, Free: free})
+}
+
+func (vm *VM) assign(slot *object.Object, value object.Object) {
+	if cellObj, ok := (*slot).(*cell); ok {
+		cellObj.value = value
+	} else {
+		*slot = value
+	}
+}
+
+// capture boxes the slot in a cell on its first capture, so that every
+// closure capturing it afterwards shares the same binding.
+func (vm *VM) capture(slot *object.Object) object.Object {
+	if _, ok := (*slot).(*cell); !ok {
+		*slot = &cell{value: *slot}
+	}
+
+	return *slot
+}
+
+// pushResult stops the program on an error object, the same way evalProgram
+// stops on the first error it meets.
+func (vm *VM) pushResult(result object.Object) error {
+	if result.GetObjectType() == object.ERROR_OBJ {
+		vm.result = result
+		return nil
+	}
+
+	return vm.push(result)
+}
+
+func (vm *VM) push(value object.Object) error {
+	vm.reserve(1)
+	vm.stack[vm.stackPointer] = value
+	vm.stackPointer++
+
+	return nil
+}
+
+// reserve grows the stack to hold count more values; no pointer into it
+// outlives an instruction, so the values can move.
+func (vm *VM) reserve(count int) {
+	if vm.stackPointer+count <= len(vm.stack) {
+		return
+	}
+
+	stack := make([]object.Object, 2*(vm.stackPointer+count))
+	copy(stack, vm.stack[:vm.stackPointer])
+	vm.stack = stack
+}
+
+func (vm *VM) pop() object.Object {
+	value := vm.stack[vm.stackPointer-1]
+	vm.stackPointer--
+
+	return value
+}
+
+func (vm *VM) readUint16() uint16 {
+	frame := vm.currentFrame()
+	value := compiler.ReadUint16(frame.Instructions()[frame.ip:])
+	frame.ip += 2
+
+	return value
+}
+
+func (vm *VM) readUint8() uint8 {
+	frame := vm.currentFrame()
+	value := compiler.ReadUint8(frame.Instructions()[frame.ip:])
+	frame.ip += 1
+
+	return value
+}
+
+func (vm *VM) currentFrame() *Frame {
+	return vm.frames[vm.framesIndex-1]
+}
+
+func (vm *VM) pushFrame(frame *Frame) error {
+	if vm.framesIndex >= len(vm.frames) {
+		return fmt.Errorf("frame overflow")
+	}
+
+	vm.frames[vm.framesIndex] = frame
+	vm.framesIndex++
+
+	return nil
+}
+
+func (vm *VM) popFrame() *Frame {
+	vm.framesIndex--
+
+	return vm.frames[vm.framesIndex]
+}
@@ -0,0 +1,253 @@
+package vm
+
+import (
+	"compiler/compiler"
+	"compiler/evaluator"
+	"compiler/lexer"
+	"compiler/object"
+	"compiler/parser"
+	"testing"
+)
+
+// crossCheckInputs run through both engines, which must agree on the result.
+var crossCheckInputs = []string{
+	`[{1n: "a"}[1], {1: "a"}[1n], [1n, 1], 2n * 3]`,
+	"let f = fn(n) { if (n == 0) { f = 5; return 0 } f(n - 1) }; f(2); f",
+	"let f = fn() { f }; let g = f; f = 1; g()",
+	"let fs = []; for (i in [1, 2, 3]) { let f = fn() { [i, f] }; fs = push(fs, f) }; fs[0]()[1] == fs[0]",
+	"let t = fn() { let fs = []; for (i in [1, 2, 3]) { let f = fn() { f }; fs = push(fs, f) }; [fs[0]() == fs[0], fs[1]() == fs[1]] }; t()",
+	"const c = fn(n) { if (n == 0) { 0 } else { c(n - 1) } }; c(5)",
+	"let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(10)",
+	"let o = fn() { let r = fn(n) { if (n == 0) { \"done\" } else { r(n - 1) } }; let q = r; r = fn(n) { \"swapped\" }; q(3) }; o()",
+	"let f = 1; let f = fn() { f }; f() == f",
+	"let t = fn() { let f = 1; let g = fn() { f }; let f = fn() { 2 }; g()() }; t()",
+	`try { 1 / 0 } catch (e) { [e["line"], e["column"], e["kind"]] }`,
+	`let f = fn(x) { x[0] }; try { f(1) } catch (e) { e }`,
+	`let f = fn() { g() }; f()`,
+	"fn isEven(n) { if (n == 0) { true } else { isOdd(n - 1) } } fn isOdd(n) { if (n == 0) { false } else { isEven(n - 1) } } [isEven(4), isOdd(7)]",
+	"fn f() { g() } fn g() { 1 } f()",
+	"let x = 0; while (true) { x += 1; if (x == 3) { break } else { 2 } }; x",
+	"let x = 0; let n = 0; while (x < 5) { x += 1; n += if (true) { while (true) { break }; 1 }; try { continue } catch (e) { 1 }; n = 100 }; [x, n]",
+	"let sum = fn(n) { if (n == 0) { 0 } else { n + sum(n - 1) } }; sum(3000)",
+	"let f = fn(n) { n + f(n + 1) }; f(0)",
+	`let f = fn(n) { n + f(n + 1) }; try { f(0) } catch (e) { [e["message"], e["line"], e["column"]] }`,
+	"fn f() { g() } let x = f(); fn g() { 1 }",
+	"let f = fn() { c }; const c = 2; f()",
+	"x; let x = 1",
+	"let add = fn(a, b) { a + b }; add(1, 2, 3)",
+	"let add = fn(a, b) { a + b }; add(1)",
+	"let id = fn(a) { a }; id()",
+	"fn(a) { a }(1, 2)",
+	"let f = fn() { 1 }; f(1)",
+	"len(1, 2)",
+	`len([1], "runes")`,
+	"push([])",
+	"let outer = fn() { let inner = fn(x) { x }; inner() }; outer()",
+	"[1, 2 * 2, 3 + 3]",
+	"let a = [1, 2, 3]; a[0] + a[2]",
+	"[1, 2, 3][3]",
+	"[1, 2, 3][-1]",
+	"let a = [1, 2, 3]; a[1 + 1]",
+	"len([1, 2])",
+	"first([5, 6])",
+	"last([5, 6])",
+	"rest([5, 6, 7])",
+	"rest([])",
+	"push([1], 2)",
+	"first(1)",
+	"push([1])",
+	"1[0]",
+	"let map = fn(arr, f) { if (len(arr) == 0) { [] } else { push(map(rest(arr), f), f(first(arr))) } }; map([1, 2, 3], fn(x) { x * 2 })",
+	"let f = fn(x) { [x, x] }; f(2)[1]",
+	"let x = 1; x = 2; x",
+	"let x = 1; x += 2; x *= 3; x -= 1; x /= 2; x %= 3; x",
+	"let c = fn() { let n = 0; fn() { n += 1 } }; let f = c(); f(); f(); f()",
+	"let a = [1, 2]; a[1] = 9; a",
+	"let h = {}; h[\"k\"] = 1; h[\"k\"] += 1; h",
+	"let a = 0; let b = 0; a = b = 3; a + b",
+	"let a = [1]; a[5] = 1",
+	"let outer = fn() { let inner = fn(n) { if (n == 0) { 0 } else { inner(n - 1) } }; inner(3) }; outer()",
+	"let g = 5; let f = fn() { g = g + 1; g }; f(); f(); g",
+	"let f = fn() { let v = 1; let s = fn(n) { v = n }; let r = fn() { v }; s(7); r() }; f()",
+	"let fs = []; for (i in [1, 2, 3]) { fs = push(fs, fn() { i }) }; fs[0]() + fs[1]() * 10",
+	"let n = 0; for (let i = 0; i < 3; i += 1) { n += i }; n",
+	"let f = fn() { let total = 0; let i = 0; while (i < 10) { i += 1; if (i % 2 == 0) { continue; } total += i; }; total }; f()",
+	"let x = 1; let x = 2; x",
+	"let t = fn() { let x = 1; let g = fn() { x }; let x = 5; g() }; t()",
+	"1 + 2 * 3",
+	"let a = 5; a * 2",
+	"let a = 5;",
+	"-5 + 10 / 2",
+	"!true",
+	"!5",
+	"!!0",
+	"\"foo\" + \"bar\"",
+	"1 < 2",
+	"1 == 1",
+	"true == true",
+	"true != false",
+	"if (1 > 2) { 10 } else { 20 }",
+	"if (1 > 2) { 10 }",
+	"if (1 < 2) { 10; }",
+	"let add = fn(a, b) { a + b }; add(2, 3)",
+	"let fib = fn(n) { if (n < 2) { return n; } fib(n - 1) + fib(n - 2) }; fib(15)",
+	"let make = fn(x) { fn(y) { x + y } }; let add2 = make(2); add2(40)",
+	"len(\"hello\")",
+	"len(1)",
+	"foo",
+	"1 + true",
+	"let f = fn() { let inner = fn(n) { if (n == 0) { 0 } else { inner(n - 1) } }; inner(10) }; f()",
+	"fn count(n) { if (n == 0) { return 0; } count(n - 1) }; count(50)",
+	"let x = 1; if (true) { let x = 2; x }",
+	"let x = 1; if (true) { let x = 2; }; x",
+	"return 5; 10",
+	"if (true) { return 3; }; 4",
+	"let f = fn(a) { let b = a * 2; fn(c) { a + b + c } }; f(1)(10)",
+	"5(1)",
+	"let f = fn(){}; f()",
+	"{\"name\": \"x\", 1: true}",
+	"let h = {\"a\": 1, \"b\": 2}; h[\"a\"] + h[\"b\"]",
+	"{true: 5}[true]",
+	"{1: 2}[2]",
+	"let k = \"a\"; {k: 1 + 1}[\"a\"]",
+	"{}",
+	"{1: 2, 1: 3}",
+	"let f = fn(x) { {\"v\": x} }; f(3)[\"v\"]",
+	"1 <= 2",
+	"2 <= 1",
+	"3 >= 3",
+	"7 % 3",
+	"-7 % 3",
+	"true && false",
+	"true && true",
+	"false || true",
+	"false || false",
+	"false && undefinedVar",
+	"true || undefinedVar",
+	"true && undefinedVar",
+	"1 && 2",
+	"0 || 0",
+	"6 & 3",
+	"6 | 3",
+	"6 ^ 3",
+	"1 << 10",
+	"1024 >> 3",
+	"1 << -1",
+	"1 + 2 == 3 && 4 > 3",
+	"1 | 2 == 2",
+	"1 < 2 == true",
+	"1 << 2 + 1",
+	"let f = fn(n) { if (n <= 1 || n % 2 == 0) { n } else { f(n - 1) } }; f(9)",
+	"try { 1 } catch (e) { 2 }",
+	"try { throw \"boom\" } catch (e) { e[\"message\"] + \"/\" + e[\"kind\"] }",
+	"try { 1 / 0 } catch (e) { e[\"message\"] + \"/\" + e[\"kind\"] }",
+	"try { throw error(\"bad\", \"ValueError\") } catch (e) { e[\"kind\"] }",
+	"let log = []; let r = try { 5 } finally { log = push(log, \"f\") }; [r, log]",
+	"let log = []; let r = try { throw \"x\" } catch { 7 } finally { log = push(log, \"f\") }; [r, log]",
+	"let log = []; try { try { throw \"x\" } finally { log = push(log, \"f\") } } catch (e) { push(log, e[\"message\"]) }",
+	"let log = []; let f = fn() { try { return 1 } finally { log = push(log, \"f\") } }; [f(), log]",
+	"let f = fn() { try { return 1 } finally { return 2 } }; f()",
+	"let f = fn() { try { throw \"a\" } catch (e) { return e[\"message\"] } finally { 9 } }; f()",
+	"let f = fn() { try { throw \"a\" } catch (e) { throw \"b\" } finally { 9 } }; try { f() } catch (e) { e[\"message\"] }",
+	"let f = fn() { try { throw \"a\" } catch (e) { throw \"b\" } }; try { f() } catch (e) { e[\"message\"] }",
+	"let n = 0; let log = []; while (n < 5) { n += 1; try { if (n == 2) { continue } if (n == 4) { break } log = push(log, n) } finally { log = push(log, \"f\" + \"\") } }; log",
+	"let g = fn(x) { if (x == 0) { throw \"deep\" } g(x - 1) }; try { g(10) } catch (e) { e[\"message\"] }",
+	"let g = fn(x) { if (x == 0) { throw \"deep\" } 1 + g(x - 1) }; let r = try { g(10) } catch (e) { 0 }; r + 1",
+	"throw \"uncaught\"",
+	"throw error(\"custom\", \"MyError\")",
+	"let e = try { throw 42 } catch (e) { e }; e[\"message\"]",
+	"try { try { throw \"in\" } catch (e) { throw \"re:\" + e[\"message\"] } } catch (e) { e[\"message\"] }",
+	"try { let x = 1; x } catch { 0 }",
+	"let s = 0; for (i in [1,2,3]) { try { if (i == 2) { throw \"skip\" } s += i } catch { s += 100 } }; s",
+	"let f = fn() { try { throw \"a\" } finally { return 3 } }; f()",
+	"let f = fn() { let i = 0; while (true) { i += 1; try { if (i < 3) { continue } return i } finally { 0 } } }; f()",
+	"let f = fn() { try { throw \"x\" } catch (e) { e } }; f()[\"kind\"]",
+	"let a = try { [1,2][5] } catch { \"no\" }; a",
+	"try { undefinedVar } catch (e) { e[\"message\"] }",
+}
+
+// TestVMLocatesErrors checks the errors the VM places differently: it
+// builds a hash in a single instruction, so it can not tell which key failed.
+func TestVMLocatesErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"{[1]: 2}", "Error: 1:1: unusable as hash key: ARRAY"},
+	}
+
+	for _, test := range tests {
+		bytecodeCompiler := compiler.New()
+
+		if err := bytecodeCompiler.Compile(parser.New(lexer.New(test.input)).ParseProgram()); err != nil {
+			t.Errorf("%s: compile error %s", test.input, err)
+			continue
+		}
+
+		machine := New(bytecodeCompiler.Bytecode())
+
+		if err := machine.Run(); err != nil {
+			t.Errorf("%s: vm error %s", test.input, err)
+			continue
+		}
+
+		if got := machine.Result().Inspect(); got != test.expected {
+			t.Errorf("%s: got %s, want %s", test.input, got, test.expected)
+		}
+	}
+}
+
+func TestVMMatchesEvaluator(t *testing.T) {
+	for _, input := range crossCheckInputs {
+		parserInstance := parser.New(lexer.New(input))
+		program := parserInstance.ParseProgram()
+
+		if errors := parserInstance.GetParsingErrors(); len(errors) > 0 {
+			t.Errorf("%s: parsing errors %v", input, errors)
+			continue
+		}
+
+		expected := evaluator.Eval(program, object.NewEnvironment())
+
+		bytecodeCompiler := compiler.New()
+
+		if err := bytecodeCompiler.Compile(program); err != nil {
+			t.Errorf("%s: compile error %s", input, err)
+			continue
+		}
+
+		machine := New(bytecodeCompiler.Bytecode())
+
+		if err := machine.Run(); err != nil {
+			t.Errorf("%s: vm error %s", input, err)
+			continue
+		}
+
+		if got, want := machine.Result().Inspect(), expected.Inspect(); got != want {
+			t.Errorf("%s: vm gives %s, evaluator gives %s", input, got, want)
+		}
+	}
+}
+
+func TestVMHonorsCallDepthLimit(t *testing.T) {
+	input := "let f = fn(n) { if (n == 0) { 0 } else { 1 + f(n - 1) } }; [f(49), try { f(50) } catch (e) { e[\"message\"] }]"
+	settings := &object.Settings{MaxCallDepth: 50}
+	program := parser.New(lexer.New(input)).ParseProgram()
+	expected := evaluator.Eval(program, object.NewEnvironmentWithSettings(settings))
+
+	bytecodeCompiler := compiler.New()
+
+	if err := bytecodeCompiler.Compile(program); err != nil {
+		t.Fatalf("compile error %s", err)
+	}
+
+	machine := NewWithSettings(bytecodeCompiler.Bytecode(), make([]object.Object, GLOBALS_SIZE), settings)
+
+	if err := machine.Run(); err != nil {
+		t.Fatalf("vm error %s", err)
+	}
+
+	if got, want := machine.Result().Inspect(), expected.Inspect(); got != want || want != "[49, maximum recursion depth 50 exceeded]" {
+		t.Errorf("vm gives %s, evaluator gives %s", got, want)
+	}
+}